@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/marzeq/mconf/mconf"
+	"github.com/marzeq/mconf/parser"
+)
+
+const (
+	CONVERT_FORMAT_MCONF = "mconf"
+	CONVERT_FORMAT_JSON  = "json"
+	CONVERT_FORMAT_YAML  = "yaml"
+	CONVERT_FORMAT_TOML  = "toml"
+)
+
+type convertOptions struct {
+	Filename   string
+	From       string
+	To         string
+	JSONIndent int
+	EnvFile    string
+	NoEnv      bool
+}
+
+func convertUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s convert [options] --to <format> [filename]
+
+Converts a file between mconf and other formats and prints the result. mconf files are evaluated first, so the output holds the final values. Reads from stdin when no filename (or '-') is given.
+
+Options:
+  -h, --help         Show this message
+  --from <format>    Format of the input: mconf, yaml or toml (defaults to the file extension, mconf for stdin)
+  --to <format>      Format of the output: mconf, json, yaml or toml
+  --indent <n>       Indent JSON output with n spaces
+  -d, --dotenv       Load .env file in current directory
+  --envfile <file>   Load specified enviorment variables file
+  --no-env           Don't fall back to environment variables for undefined constants
+
+Examples:
+  %s convert --to mconf config.yaml > config.mconf
+  %s convert --to yaml config.mconf
+  %s convert --from toml --to mconf < Cargo.toml`, progname, progname, progname, progname)
+}
+
+func isConvertFormat(format string, formats ...string) bool {
+	for _, f := range formats {
+		if format == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatFromExtension guesses the format of a file from its name
+func formatFromExtension(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return CONVERT_FORMAT_YAML
+	case ".toml":
+		return CONVERT_FORMAT_TOML
+	default:
+		return CONVERT_FORMAT_MCONF
+	}
+}
+
+func parseConvertOptions(progname string, args []string) (convertOptions, string, uint) {
+	opts := convertOptions{Filename: "-"}
+	providedFilename := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "-h", "--help":
+			return opts, convertUsage(progname), 0
+		case "-d", "--dotenv":
+			opts.EnvFile = ".env"
+		case "--no-env":
+			opts.NoEnv = true
+		case "--from", "--to", "--indent", "--envfile":
+			if i+1 >= len(args) {
+				return opts, fmt.Sprintf("No argument provided for %s", arg), 1
+			}
+
+			switch arg {
+			case "--from":
+				opts.From = args[i+1]
+			case "--to":
+				opts.To = args[i+1]
+			case "--envfile":
+				opts.EnvFile = args[i+1]
+			default:
+				indent, err := strconv.Atoi(args[i+1])
+				if err != nil || indent < 0 {
+					return opts, fmt.Sprintf("Invalid indent `%s`, expected a number of spaces", args[i+1]), 1
+				}
+
+				opts.JSONIndent = indent
+			}
+
+			i++
+		default:
+			if providedFilename {
+				return opts, "Provided multiple filenames, only one is allowed", 1
+			}
+
+			opts.Filename = arg
+			providedFilename = true
+		}
+	}
+
+	if opts.From == "" {
+		opts.From = CONVERT_FORMAT_MCONF
+
+		if opts.Filename != "-" {
+			opts.From = formatFromExtension(opts.Filename)
+		}
+	}
+
+	if !isConvertFormat(opts.From, CONVERT_FORMAT_MCONF, CONVERT_FORMAT_YAML, CONVERT_FORMAT_TOML) {
+		return opts, fmt.Sprintf("Unknown input format %s, expected mconf, yaml or toml", opts.From), 1
+	}
+
+	if opts.To == "" {
+		return opts, "No output format provided, use --to", 1
+	}
+
+	if !isConvertFormat(opts.To, CONVERT_FORMAT_MCONF, CONVERT_FORMAT_JSON, CONVERT_FORMAT_YAML, CONVERT_FORMAT_TOML) {
+		return opts, fmt.Sprintf("Unknown output format %s, expected mconf, json, yaml or toml", opts.To), 1
+	}
+
+	return opts, "", 0
+}
+
+// readInput reads the value to convert in the given format
+func readInput(opts convertOptions) (parser.ParserValue, error) {
+	if opts.From == CONVERT_FORMAT_MCONF {
+		env, err := environment(options{EnvFile: opts.EnvFile, NoEnv: opts.NoEnv})
+		if err != nil {
+			return nil, err
+		}
+
+		var result *mconf.Result
+
+		if opts.Filename == "-" {
+			result, err = mconf.ParseReader(os.Stdin, mconf.WithEnv(env))
+		} else {
+			result, err = mconf.Load(opts.Filename, mconf.WithEnv(env))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Object(), nil
+	}
+
+	var data []byte
+	var err error
+
+	if opts.Filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(opts.Filename)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error reading file %s", opts.Filename)
+	}
+
+	if opts.From == CONVERT_FORMAT_TOML {
+		return mconf.FromTOML(data)
+	}
+
+	return mconf.FromYAML(data)
+}
+
+func runConvert(progname string, args []string) int {
+	opts, message, exitcode := parseConvertOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	value, err := readInput(opts)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	switch opts.To {
+	case CONVERT_FORMAT_JSON:
+		err = parser.NewJSONEncoder(os.Stdout, parser.JSONOptions{Indent: opts.JSONIndent}).Encode(value)
+	case CONVERT_FORMAT_YAML, CONVERT_FORMAT_TOML:
+		var out []byte
+
+		if opts.To == CONVERT_FORMAT_YAML {
+			out, err = mconf.ToYAML(value)
+		} else {
+			out, err = mconf.ToTOML(value)
+		}
+
+		os.Stdout.Write(out)
+	default:
+		obj, ok := value.(*parser.ParserValueObject)
+		if !ok {
+			fmt.Printf("Only objects can be written as mconf, the converted value is a %s\n", strings.ToLower(value.GetType()))
+			return 1
+		}
+
+		fmt.Print(obj.TopLevelString(2))
+	}
+
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marzeq/mconf/mconf"
+	"github.com/marzeq/mconf/parser"
+)
+
+const (
+	DIFF_FORMAT_HUMAN = "human"
+	DIFF_FORMAT_JSON  = "json"
+	DIFF_FORMAT_PATCH = "patch"
+)
+
+type diffOptions struct {
+	Filenames         []string
+	AcessedProperties []string
+	Format            string
+	EnvFile           string
+	NoEnv             bool
+}
+
+func diffUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s diff [options] <old> <new> [-- property1 property2 ...]
+
+Evaluates both files and lists the values that were added, removed or changed between them. Exits with 0 when they are the same, 1 when they differ and 2 on errors.
+
+Options:
+  -h, --help             Show this message
+  -f, --format <format>  Output format: human (default), json or patch (a JSON Patch, RFC 6902, that turns <old> into <new>)
+  -d, --dotenv           Load .env file in current directory
+  --envfile <file>       Load specified enviorment variables file
+  --no-env               Don't fall back to environment variables for undefined constants
+
+Examples:
+  %s diff old.mconf new.mconf
+  %s diff -f patch old.mconf new.mconf -- servers`, progname, progname, progname)
+}
+
+func parseDiffOptions(progname string, args []string) (diffOptions, string, uint) {
+	opts := diffOptions{Format: DIFF_FORMAT_HUMAN}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "--":
+			opts.AcessedProperties = args[i+1:]
+			i = len(args)
+		case "-h", "--help":
+			return opts, diffUsage(progname), 0
+		case "-d", "--dotenv":
+			opts.EnvFile = ".env"
+		case "--no-env":
+			opts.NoEnv = true
+		case "--envfile", "-f", "--format":
+			if i+1 >= len(args) {
+				return opts, fmt.Sprintf("No argument provided for %s", arg), 2
+			}
+
+			if arg == "--envfile" {
+				opts.EnvFile = args[i+1]
+			} else {
+				opts.Format = args[i+1]
+			}
+
+			i++
+		default:
+			opts.Filenames = append(opts.Filenames, arg)
+		}
+	}
+
+	if opts.Format != DIFF_FORMAT_HUMAN && opts.Format != DIFF_FORMAT_JSON && opts.Format != DIFF_FORMAT_PATCH {
+		return opts, fmt.Sprintf("Unknown format %s, expected human, json or patch", opts.Format), 2
+	}
+
+	if len(opts.Filenames) != 2 {
+		return opts, diffUsage(progname), 2
+	}
+
+	return opts, "", 0
+}
+
+// loadSubtree evaluates filename and looks up the accessed properties in it
+func loadSubtree(filename string, properties []string, opts ...mconf.Option) (parser.ParserValue, error) {
+	result, err := mconf.Load(filename, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return lookupProperties(result, properties)
+}
+
+func runDiff(progname string, args []string) int {
+	opts, message, exitcode := parseDiffOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	env, err := environment(options{EnvFile: opts.EnvFile, NoEnv: opts.NoEnv})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	values := []parser.ParserValue{}
+
+	for _, filename := range opts.Filenames {
+		value, err := loadSubtree(filename, opts.AcessedProperties, mconf.WithEnv(env))
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
+
+		values = append(values, value)
+	}
+
+	changes := mconf.Diff(values[0], values[1])
+
+	switch opts.Format {
+	case DIFF_FORMAT_JSON, DIFF_FORMAT_PATCH:
+		document := mconf.ChangesToValue(changes)
+
+		if opts.Format == DIFF_FORMAT_PATCH {
+			document = mconf.ChangesToPatch(changes)
+		}
+
+		err := parser.NewJSONEncoder(os.Stdout, parser.JSONOptions{Indent: 2}).Encode(document)
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
+	default:
+		for _, c := range changes {
+			path := c.Path
+			if path == "" {
+				path = "(root)"
+			}
+
+			switch c.Type {
+			case mconf.CHANGE_TYPE_ADDED:
+				fmt.Printf("+ %s = %s\n", path, c.New.ValueToString())
+			case mconf.CHANGE_TYPE_REMOVED:
+				fmt.Printf("- %s = %s\n", path, c.Old.ValueToString())
+			default:
+				fmt.Printf("~ %s = %s -> %s\n", path, c.Old.ValueToString(), c.New.ValueToString())
+			}
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+
+	return 0
+}
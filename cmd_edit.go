@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/mconf"
+	"github.com/marzeq/mconf/parser"
+	"github.com/marzeq/mconf/syntax"
+)
+
+type editOptions struct {
+	Filename string
+	Path     []string
+	Value    string
+	IsString bool
+	DryRun   bool
+}
+
+func editUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s set [-s] [-n] <filename> <path> <value>
+  %s del [-n] <filename> <path>
+
+Changes a single value of a file in place. Only the definition of the value is touched, so comments and formatting are kept. <path> uses the same syntax as property access, like db.port or servers[2].host.
+
+set replaces the value at <path> with <value>, which is written like any mconf value ("text", 5433, [1, 2], { a = 1 }). A key that isn't defined yet is added.
+del removes the value at <path>, if a key is defined more than once every definition of it is removed.
+
+Values defined in imported files can't be edited, edit the imported file instead.
+
+Options:
+  -h, --help     Show this message
+  -s, --string   Use <value> as a plain string instead of reading it as an mconf value
+  -n, --dry-run  Print the edited file instead of writing it
+
+Examples:
+  %s set config.mconf db.port 5433
+  %s set -s config.mconf db.host db.internal
+  %s del config.mconf db.legacy`, progname, progname, progname, progname, progname)
+}
+
+func parseEditOptions(progname string, command string, args []string) (editOptions, string, uint) {
+	opts := editOptions{}
+	positional := []string{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		switch arg {
+		case "-h", "--help":
+			return opts, editUsage(progname), 0
+		case "-s", "--string":
+			opts.IsString = true
+		case "-n", "--dry-run":
+			opts.DryRun = true
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	expected := 2
+	if command == "set" {
+		expected = 3
+	}
+
+	if len(positional) != expected {
+		return opts, editUsage(progname), 1
+	}
+
+	opts.Filename = positional[0]
+
+	path, err := parser.ParsePath(positional[1])
+	if err != nil {
+		return opts, err.Error(), 1
+	}
+
+	opts.Path = path
+
+	if command == "set" {
+		opts.Value = positional[2]
+
+		if opts.IsString {
+			opts.Value = (&parser.ParserValueString{Value: opts.Value}).ValueToString()
+		}
+	}
+
+	return opts, "", 0
+}
+
+// importedKeys loads the files imported by filename to tell which keys they define
+func importedKeys(filename string) syntax.ImportedKeys {
+	dir := filepath.Dir(filename)
+
+	return func(importPath string) ([]string, error) {
+		result, err := mconf.Load(filepath.Join(dir, importPath), mconf.WithDir(dir), mconf.WithFilename(importPath))
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Values.Keys(), nil
+	}
+}
+
+func runEdit(progname string, command string, args []string) int {
+	opts, message, exitcode := parseEditOptions(progname, command, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	src, err := os.ReadFile(opts.Filename)
+	if err != nil {
+		fmt.Printf("Error reading file %s\n", opts.Filename)
+		return 1
+	}
+
+	var edited []byte
+
+	if command == "set" {
+		edited, err = syntax.Set(src, opts.Filename, opts.Path, opts.Value, importedKeys(opts.Filename))
+	} else {
+		edited, err = syntax.Delete(src, opts.Filename, opts.Path, importedKeys(opts.Filename))
+	}
+
+	if err != nil {
+		fmt.Println(err)
+
+		var syntaxErr *diagnostics.SyntaxError
+		if command == "set" && errors.As(err, &syntaxErr) && syntaxErr.File == syntax.VALUE_FILENAME {
+			fmt.Println("Strings have to be quoted, use -s to set a plain string")
+		}
+
+		return 1
+	}
+
+	if opts.DryRun {
+		os.Stdout.Write(edited)
+		return 0
+	}
+
+	err = writeFileAtomic(opts.Filename, edited)
+	if err != nil {
+		fmt.Printf("Error writing file %s: %s\n", opts.Filename, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/marzeq/mconf/mconf"
+)
+
+// commandExitCode is what exec exits with when the command can't be started, the same code shells use for a missing command
+const commandExitCode = 127
+
+type execOptions struct {
+	Filename string
+	Path     string
+	Flatten  mconf.FlattenOptions
+	EnvFile  string
+	NoEnv    bool
+	Command  []string
+}
+
+func execUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s exec [options] <filename> -- <command> [arguments...]
+
+Evaluates the file, turns its values into environment variables and runs the command with them added to the current environment. Nested keys are joined with '_' and upper cased, so db.host becomes DB_HOST. The exit code and signals of the command are passed through.
+
+Options:
+  -h, --help          Show this message
+  --prefix <prefix>   Put prefix in front of every variable name (e.g. APP_)
+  --path <path>       Only export the values under path (e.g. server or servers[0])
+  --case <case>       Case of variable names: upper (default), lower or preserve
+  --separator <sep>   Join the keys of nested values with sep (default '_')
+  --lists <mode>      How lists are exported: indexed (default, TAGS_0, TAGS_1...) or json (a single variable)
+  -d, --dotenv        Load .env file in current directory (its variables are passed to the command too)
+  --envfile <file>    Load specified enviorment variables file
+  --no-env            Don't fall back to environment variables for undefined constants
+
+Examples:
+  %s exec app.mconf -- ./server
+  %s exec app.mconf --prefix APP_ --path server -- ./server --verbose`, progname, progname, progname)
+}
+
+func parseExecOptions(progname string, args []string) (execOptions, string, uint) {
+	opts := execOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "--":
+			opts.Command = args[i+1:]
+			i = len(args)
+		case "-h", "--help":
+			return opts, execUsage(progname), 0
+		case "-d", "--dotenv":
+			opts.EnvFile = ".env"
+		case "--no-env":
+			opts.NoEnv = true
+		case "--prefix", "--path", "--case", "--separator", "--lists", "--envfile":
+			if i+1 >= len(args) {
+				return opts, fmt.Sprintf("No argument provided for %s", arg), 1
+			}
+
+			switch arg {
+			case "--prefix":
+				opts.Flatten.Prefix = args[i+1]
+			case "--path":
+				opts.Path = args[i+1]
+			case "--case":
+				opts.Flatten.Case = args[i+1]
+			case "--separator":
+				opts.Flatten.Separator = args[i+1]
+			case "--lists":
+				opts.Flatten.Lists = args[i+1]
+			default:
+				opts.EnvFile = args[i+1]
+			}
+
+			i++
+		default:
+			if opts.Filename != "" {
+				return opts, "Provided multiple filenames, only one is allowed", 1
+			}
+
+			opts.Filename = arg
+		}
+	}
+
+	if opts.Filename == "" || len(opts.Command) == 0 {
+		return opts, execUsage(progname), 1
+	}
+
+	return opts, "", 0
+}
+
+// loadEnvFile reads the variables of the env file, none when filename is empty
+func loadEnvFile(filename string) (map[string]string, error) {
+	env := map[string]string{}
+
+	if filename == "" {
+		return env, nil
+	}
+
+	if err := readEnvFile(filename, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// childEnvironment is the current environment with the variables of the env file and then vars laid over it
+func childEnvironment(envFile map[string]string, vars []mconf.EnvVar) []string {
+	fromConfig := map[string]bool{}
+
+	for _, v := range vars {
+		fromConfig[v.Name] = true
+	}
+
+	env := []string{}
+
+	for _, e := range os.Environ() {
+		name, _, _ := strings.Cut(e, "=")
+
+		if _, ok := envFile[name]; !ok && !fromConfig[name] {
+			env = append(env, e)
+		}
+	}
+
+	names := []string{}
+
+	for name := range envFile {
+		if !fromConfig[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = append(env, name+"="+envFile[name])
+	}
+
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+	}
+
+	return env
+}
+
+func runExec(progname string, args []string) int {
+	opts, message, exitcode := parseExecOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	env, err := environment(options{EnvFile: opts.EnvFile, NoEnv: opts.NoEnv})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	properties := []string{}
+
+	if opts.Path != "" {
+		properties = append(properties, opts.Path)
+	}
+
+	value, err := loadSubtree(opts.Filename, properties, mconf.WithEnv(env))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	vars, err := mconf.Flatten(value, opts.Flatten)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	envFile, err := loadEnvFile(opts.EnvFile)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	return execCommand(opts.Command, childEnvironment(envFile, vars))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/marzeq/mconf/syntax"
+)
+
+type fmtOptions struct {
+	Filenames []string
+	Write     bool
+	Check     bool
+}
+
+func fmtUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s fmt [-w] [--check] [files...]
+
+Rewrites mconf files in the canonical style, keeping comments, blank lines between entries and the original spelling of values. Without files, stdin is formatted to stdout.
+
+Options:
+  -h, --help   Show this message
+  -w, --write  Write the result back to the files instead of printing it
+  --check      Don't print or write anything, list the files that aren't formatted and exit with 1 if there are any
+
+Examples:
+  %s fmt config.mconf
+  %s fmt -w *.mconf
+  %s fmt --check config.mconf`, progname, progname, progname, progname)
+}
+
+func parseFmtOptions(progname string, args []string) (fmtOptions, string, uint) {
+	opts := fmtOptions{}
+
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			return opts, fmtUsage(progname), 0
+		case "-w", "--write":
+			opts.Write = true
+		case "--check":
+			opts.Check = true
+		default:
+			if len(arg) > 1 && arg[0] == '-' {
+				return opts, fmt.Sprintf("Unknown option %s", arg), 1
+			}
+
+			opts.Filenames = append(opts.Filenames, arg)
+		}
+	}
+
+	if opts.Write && opts.Check {
+		return opts, "-w and --check can't be used together", 1
+	}
+
+	return opts, "", 0
+}
+
+func runFmt(progname string, args []string) int {
+	opts, message, exitcode := parseFmtOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	if len(opts.Filenames) == 0 {
+		opts.Filenames = []string{"-"}
+	}
+
+	exitcode = 0
+
+	for _, filename := range opts.Filenames {
+		var src []byte
+		var err error
+
+		if filename == "-" {
+			if opts.Write {
+				fmt.Println("Can't use -w when formatting stdin")
+				return 1
+			}
+
+			src, err = io.ReadAll(os.Stdin)
+		} else {
+			src, err = os.ReadFile(filename)
+		}
+
+		if err != nil {
+			fmt.Printf("Error reading file %s\n", filename)
+			exitcode = 1
+			continue
+		}
+
+		name := filename
+		if name == "-" {
+			name = ""
+		}
+
+		formatted, err := syntax.Format(src, name)
+		if err != nil {
+			fmt.Println(err)
+			exitcode = 1
+			continue
+		}
+
+		switch {
+		case opts.Check:
+			if !bytes.Equal(src, formatted) {
+				fmt.Println(filename)
+				exitcode = 1
+			}
+		case opts.Write:
+			if bytes.Equal(src, formatted) {
+				continue
+			}
+
+			err := writeFileAtomic(filename, formatted)
+			if err != nil {
+				fmt.Printf("Error writing file %s: %s\n", filename, err)
+				exitcode = 1
+			}
+		default:
+			os.Stdout.Write(formatted)
+		}
+	}
+
+	return int(exitcode)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/marzeq/mconf/mconf"
+	"github.com/marzeq/mconf/parser"
+)
+
+type mergeOptions struct {
+	Filenames  []string
+	Lists      string
+	ToJson     bool
+	JSONIndent int
+	EnvFile    string
+	NoEnv      bool
+}
+
+func mergeUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s merge [options] <base> <override>...
+
+Evaluates every file and deep merges them in order, later files override earlier ones. Objects are merged key by key, lists are combined according to --lists and any other value is replaced. An override file can remove a key of the files before it with '@unset path.to.key'.
+
+Options:
+  -h, --help          Show this message
+  --lists <strategy>  How lists under the same key are combined: replace (default), append or unique-append (append the elements that aren't there yet)
+  -j, --json          Output as JSON instead of mconf
+  --indent <n>        Indent JSON output with n spaces (implies --json)
+  -d, --dotenv        Load .env file in current directory
+  --envfile <file>    Load specified enviorment variables file
+  --no-env            Don't fall back to environment variables for undefined constants
+
+Examples:
+  %s merge base.mconf production.mconf
+  %s merge --lists unique-append -j base.mconf local.mconf`, progname, progname, progname)
+}
+
+func parseMergeOptions(progname string, args []string) (mergeOptions, string, uint) {
+	opts := mergeOptions{Lists: parser.MERGE_LISTS_REPLACE}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "-h", "--help":
+			return opts, mergeUsage(progname), 0
+		case "-j", "--json":
+			opts.ToJson = true
+		case "-d", "--dotenv":
+			opts.EnvFile = ".env"
+		case "--no-env":
+			opts.NoEnv = true
+		case "--lists", "--indent", "--envfile":
+			if i+1 >= len(args) {
+				return opts, fmt.Sprintf("No argument provided for %s", arg), 1
+			}
+
+			switch arg {
+			case "--lists":
+				opts.Lists = args[i+1]
+			case "--envfile":
+				opts.EnvFile = args[i+1]
+			default:
+				indent, err := strconv.Atoi(args[i+1])
+				if err != nil || indent < 0 {
+					return opts, fmt.Sprintf("Invalid indent `%s`, expected a number of spaces", args[i+1]), 1
+				}
+
+				opts.ToJson = true
+				opts.JSONIndent = indent
+			}
+
+			i++
+		default:
+			opts.Filenames = append(opts.Filenames, arg)
+		}
+	}
+
+	if err := parser.ValidateMergeOptions(parser.MergeOptions{Lists: opts.Lists}); err != nil {
+		return opts, err.Error(), 1
+	}
+
+	if len(opts.Filenames) == 0 {
+		return opts, mergeUsage(progname), 1
+	}
+
+	return opts, "", 0
+}
+
+func runMerge(progname string, args []string) int {
+	opts, message, exitcode := parseMergeOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	env, err := environment(options{EnvFile: opts.EnvFile, NoEnv: opts.NoEnv})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	result, err := mconf.MergeFiles(opts.Filenames, parser.MergeOptions{Lists: opts.Lists}, mconf.WithEnv(env))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if opts.ToJson {
+		err := parser.NewJSONEncoder(os.Stdout, parser.JSONOptions{Indent: opts.JSONIndent}).Encode(result.Object())
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+
+		return 0
+	}
+
+	fmt.Print(result.Object().TopLevelString(2))
+
+	return 0
+}
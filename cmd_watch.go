@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/marzeq/mconf/mconf"
+	"github.com/marzeq/mconf/parser"
+)
+
+// interruptExitCode is what watch exits with when it's interrupted, the same code shells use for a command killed by SIGINT
+const interruptExitCode = 130
+
+type watchOptions struct {
+	Filename          string
+	AcessedProperties []string
+	ToJson            bool
+	JSONIndent        int
+	EnvFile           string
+	NoEnv             bool
+	Run               string
+	Prefix            string
+	Interval          time.Duration
+}
+
+func watchUsage(progname string) string {
+	return fmt.Sprintf(`Usage:
+  %s watch [options] <filename> [-- property1 property2 ...]
+
+Evaluates the file and prints the result (like '%s <filename>' does) every time the file, a file it imports or the --envfile changes. Errors are printed and watching goes on. The files imports point to are looked up again after every change, so added and removed imports are picked up.
+
+Options:
+  -h, --help          Show this message
+  -j, --json          Output as JSON
+  --indent <n>        Indent JSON output with n spaces (implies --json)
+  --run <command>     Run command through the shell after every successful evaluation instead of printing, with the values as environment variables like '%s exec' does. A run that is still going is stopped first, along with anything it started
+  --prefix <prefix>   Put prefix in front of every variable name given to --run
+  --interval <time>   How often files are checked for changes (default 500ms)
+  -d, --dotenv        Load .env file in current directory
+  --envfile <file>    Load specified enviorment variables file
+  --no-env            Don't fall back to environment variables for undefined constants
+
+Examples:
+  %s watch app.mconf -- server
+  %s watch app.mconf --run './server'`, progname, progname, progname, progname, progname)
+}
+
+func parseWatchOptions(progname string, args []string) (watchOptions, string, uint) {
+	opts := watchOptions{Interval: 500 * time.Millisecond}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "--":
+			opts.AcessedProperties = args[i+1:]
+			i = len(args)
+		case "-h", "--help":
+			return opts, watchUsage(progname), 0
+		case "-j", "--json":
+			opts.ToJson = true
+		case "-d", "--dotenv":
+			opts.EnvFile = ".env"
+		case "--no-env":
+			opts.NoEnv = true
+		case "--indent", "--run", "--prefix", "--interval", "--envfile":
+			if i+1 >= len(args) {
+				return opts, fmt.Sprintf("No argument provided for %s", arg), 1
+			}
+
+			switch arg {
+			case "--indent":
+				indent, err := strconv.Atoi(args[i+1])
+				if err != nil || indent < 0 {
+					return opts, fmt.Sprintf("Invalid indent `%s`, expected a number of spaces", args[i+1]), 1
+				}
+
+				opts.ToJson = true
+				opts.JSONIndent = indent
+			case "--run":
+				opts.Run = args[i+1]
+			case "--prefix":
+				opts.Prefix = args[i+1]
+			case "--interval":
+				interval, err := time.ParseDuration(args[i+1])
+				if err != nil || interval <= 0 {
+					return opts, fmt.Sprintf("Invalid interval `%s`, expected a duration like 500ms or 2s", args[i+1]), 1
+				}
+
+				opts.Interval = interval
+			default:
+				opts.EnvFile = args[i+1]
+			}
+
+			i++
+		default:
+			if opts.Filename != "" {
+				return opts, "Provided multiple filenames, only one is allowed", 1
+			}
+
+			opts.Filename = arg
+		}
+	}
+
+	if opts.Filename == "" {
+		return opts, watchUsage(progname), 1
+	}
+
+	return opts, "", 0
+}
+
+// fileState is what a file looked like when it was last checked, a missing file has the zero state
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func statFiles(files []string) map[string]fileState {
+	states := map[string]fileState{}
+
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			states[f] = fileState{}
+			continue
+		}
+
+		states[f] = fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return states
+}
+
+// changed reports whether any of the files looks different than it did in states
+func changed(states map[string]fileState) bool {
+	files := []string{}
+
+	for f := range states {
+		files = append(files, f)
+	}
+
+	for f, state := range statFiles(files) {
+		if state != states[f] {
+			return true
+		}
+	}
+
+	return false
+}
+
+type watcher struct {
+	opts watchOptions
+	// running is the last command started by --run and done is closed once it has exited
+	running *exec.Cmd
+	done    chan struct{}
+}
+
+// evaluate parses the file once and prints or runs the result, it returns the files that the next change should be looked for in
+func (w *watcher) evaluate() []string {
+	files := []string{w.opts.Filename}
+
+	if w.opts.EnvFile != "" {
+		files = append(files, w.opts.EnvFile)
+	}
+
+	env, err := environment(options{EnvFile: w.opts.EnvFile, NoEnv: w.opts.NoEnv})
+	if err != nil {
+		fmt.Println(err)
+		return files
+	}
+
+	readFiles := []string{}
+	value, err := loadSubtree(w.opts.Filename, w.opts.AcessedProperties, mconf.WithEnv(env), mconf.WithReadFiles(&readFiles))
+	files = append(files, readFiles...)
+
+	if err != nil {
+		fmt.Println(err)
+		return files
+	}
+
+	if w.opts.Run != "" {
+		envFile, err := loadEnvFile(w.opts.EnvFile)
+		if err != nil {
+			fmt.Println(err)
+			return files
+		}
+
+		w.run(value, envFile)
+		return files
+	}
+
+	if w.opts.ToJson {
+		err := parser.NewJSONEncoder(os.Stdout, parser.JSONOptions{Indent: w.opts.JSONIndent}).Encode(value)
+		if err != nil {
+			fmt.Println(err)
+		}
+	} else if value.GetType() == parser.PARSER_VALUE_TYPE_STRING {
+		s, _ := value.GetString()
+		fmt.Println(s)
+	} else {
+		fmt.Println(value.ValueToString(2))
+	}
+
+	return files
+}
+
+// run stops the previous run of the command if it's still going and starts it again with value and the env file in its environment
+func (w *watcher) run(value parser.ParserValue, envFile map[string]string) {
+	vars, err := mconf.Flatten(value, mconf.FlattenOptions{Prefix: w.opts.Prefix})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	w.stop()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, flag, w.opts.Run)
+	cmd.Env = childEnvironment(envFile, vars)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// the shell runs the command as a child, so it's started in a group of its own that stop can kill as a whole
+	startInGroup(cmd)
+
+	err = cmd.Start()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	done := make(chan struct{})
+	w.running = cmd
+	w.done = done
+
+	go func() {
+		err := cmd.Wait()
+		close(done)
+
+		// a run killed by stop has no exit code
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			fmt.Printf("%s exited with code %d\n", w.opts.Run, exitErr.ExitCode())
+		}
+	}()
+}
+
+// stop kills the last run if it's still going and waits for it to exit
+func (w *watcher) stop() {
+	if w.running == nil {
+		return
+	}
+
+	select {
+	case <-w.done:
+	default:
+		killGroup(w.running)
+		<-w.done
+	}
+
+	w.running = nil
+}
+
+func runWatch(progname string, args []string) int {
+	opts, message, exitcode := parseWatchOptions(progname, args)
+
+	if message != "" {
+		fmt.Println(message)
+		return int(exitcode)
+	}
+
+	// the command doesn't get the terminal's interrupts in a group of its own, so it's stopped before exiting
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	w := watcher{opts: opts}
+	states := statFiles(w.evaluate())
+
+	for {
+		select {
+		case <-signals:
+			w.stop()
+			return interruptExitCode
+		case <-time.After(opts.Interval):
+		}
+
+		if !changed(states) {
+			continue
+		}
+
+		fmt.Printf("[%s] change detected, evaluating %s again\n", time.Now().Format("15:04:05"), opts.Filename)
+		states = statFiles(w.evaluate())
+	}
+}
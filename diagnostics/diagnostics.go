@@ -0,0 +1,138 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	STAGE_TOKENISER = "Tokeniser"
+	STAGE_PARSER    = "Parser"
+)
+
+const (
+	CODE_UNEXPECTED_CHARACTER  = "UNEXPECTED_CHARACTER"
+	CODE_UNEXPECTED_TOKEN      = "UNEXPECTED_TOKEN"
+	CODE_UNTERMINATED_STRING   = "UNTERMINATED_STRING"
+	CODE_INVALID_ESCAPE        = "INVALID_ESCAPE"
+	CODE_INVALID_SUBSTITUTION  = "INVALID_SUBSTITUTION"
+	CODE_INVALID_NUMBER        = "INVALID_NUMBER"
+	CODE_UNKNOWN_DIRECTIVE     = "UNKNOWN_DIRECTIVE"
+	CODE_CONSTANT_NOT_FOUND    = "CONSTANT_NOT_FOUND"
+	CODE_TYPE_MISMATCH         = "TYPE_MISMATCH"
+	CODE_DIVISION_BY_ZERO      = "DIVISION_BY_ZERO"
+	CODE_UNKNOWN_FUNCTION      = "UNKNOWN_FUNCTION"
+	CODE_ARGUMENT_COUNT        = "ARGUMENT_COUNT"
+	CODE_INVALID_CONVERSION    = "INVALID_CONVERSION"
+	CODE_IMPORT_SELF           = "IMPORT_SELF"
+	CODE_IMPORT_READ_FAILED    = "IMPORT_READ_FAILED"
+	CODE_IMPORT_PATH_NOT_FOUND = "IMPORT_PATH_NOT_FOUND"
+	CODE_IMPORT_NOT_OBJECT     = "IMPORT_NOT_OBJECT"
+	CODE_INTERNAL              = "INTERNAL"
+)
+
+// Position points at a place in a source file, Line and Col are both 0 at the end of the file
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (p Position) String() string {
+	if p.Line == 0 && p.Col == 0 {
+		return fmt.Sprintf("%s (EOF)", p.File)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// Diagnostic holds what every error reported by the tokeniser or parser carries
+type Diagnostic struct {
+	Position
+	Stage   string
+	Code    string
+	Message string
+}
+
+func (d *Diagnostic) Error() string {
+	// errors raised outside of any file (e.g. by a value getter) have no position to report
+	if d.File == "" {
+		return d.Message
+	}
+
+	return fmt.Sprintf("%s - %s error: %s", d.Position, d.Stage, d.Message)
+}
+
+// SyntaxError is reported for source that doesn't follow the grammar
+type SyntaxError struct {
+	Diagnostic
+}
+
+// ImportError is reported when an @import directive can't be satisfied, Err holds the underlying error if there is one
+type ImportError struct {
+	Diagnostic
+	Path string
+	Err  error
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// ConstantNotFoundError is reported when a constant is neither defined nor in the environment and has no default
+type ConstantNotFoundError struct {
+	Diagnostic
+	Name string
+}
+
+// TypeError is reported when a value is used as a type it doesn't have
+type TypeError struct {
+	Diagnostic
+	Expected string
+	Actual   string
+}
+
+// EvaluationError is reported when an expression has the right types but still can't be evaluated, like a division by zero
+type EvaluationError struct {
+	Diagnostic
+}
+
+// List collects every error found in a file and its imports
+type List []error
+
+func (l List) Error() string {
+	messages := make([]string, len(l))
+
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func (l List) Unwrap() []error {
+	return l
+}
+
+// Add appends err, the errors of a nested List are added one by one
+func (l *List) Add(err error) {
+	if err == nil {
+		return
+	}
+
+	if nested, ok := err.(List); ok {
+		*l = append(*l, nested...)
+		return
+	}
+
+	*l = append(*l, err)
+}
+
+// Err returns the list as an error, or nil if it is empty
+func (l List) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+
+	return l
+}
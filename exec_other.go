@@ -0,0 +1,59 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// execCommand runs the command as a child process where processes can't be replaced, forwarding interrupts to it and
+// returning its exit code
+func execCommand(command []string, env []string) int {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Start()
+	if err != nil {
+		fmt.Println(err)
+		return commandExitCode
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err = cmd.Wait()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	return 0
+}
+
+// startInGroup does nothing where there are no process groups
+func startInGroup(cmd *exec.Cmd) {}
+
+// killGroup kills cmd's process, the processes it started are left running where there are no process groups
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// execCommand replaces the current process with the command, so its exit code and the signals sent to it need no forwarding
+func execCommand(command []string, env []string) int {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		fmt.Println(err)
+		return commandExitCode
+	}
+
+	err = syscall.Exec(path, command, env)
+
+	// Exec only returns when it failed
+	fmt.Printf("Error running %s: %s\n", command[0], err)
+	return commandExitCode
+}
+
+// startInGroup makes cmd start in a process group of its own, so that killGroup also reaches the processes it starts
+func startInGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup kills the process group started by cmd
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces filename with data through a temporary file in the same directory, so that a crash
+// halfway through never leaves a half written config behind. the file keeps its permissions
+func writeFileAtomic(filename string, data []byte) error {
+	perm := os.FileMode(0644)
+
+	info, err := os.Stat(filename)
+	if err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpName, filename)
+	}
+
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return nil
+}
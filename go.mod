@@ -1,3 +1,7 @@
 module github.com/marzeq/mconf
 
 go 1.22.5
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/BurntSushi/toml v1.5.0
@@ -2,89 +2,37 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/marzeq/mconf/mconf"
 	"github.com/marzeq/mconf/parser"
-	"github.com/marzeq/mconf/tokeniser"
 )
 
-func check(err error) {
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
-
-func ParseFromString(s string, rootDir string, rootFile string, relativeDir string) (map[string]parser.ParserValue, map[string]parser.ParserValue, error) {
-	t := tokeniser.NewTokeniser(s, rootFile, relativeDir)
-	tokens, err := t.Tokenise()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	p := parser.NewParser(tokens, rootDir, rootFile, relativeDir)
-	parsed, err := p.Parse()
-	if err != nil {
-		return nil, nil, err
-	}
-	constants := p.GetConstants()
+// lookupProperties looks up the accessed properties in result
+func lookupProperties(result *mconf.Result, properties []string) (parser.ParserValue, error) {
+	path := properties
 
-	return parsed, constants, nil
-}
-
-func ParseFromFile(filename string) (map[string]parser.ParserValue, map[string]parser.ParserValue, error) {
-	f, err := os.ReadFile(filename)
-	if err != nil {
-		err = fmt.Errorf("%s - Error reading file,%s", filename, strings.Split(err.Error(), ":")[1])
-		return nil, nil, err
-	}
+	// a single property can be a whole path unless it is a key itself, several of them are taken as they are so that any key can be reached
+	if len(path) == 1 && path[0] != "" && !result.Values.Has(path[0]) {
+		var err error
 
-	s := string(f)
-
-	relativeDir := filepath.Dir(filename)
-
-	fileDir, fdirErr := filepath.Abs(relativeDir)
-	if fdirErr != nil {
-		return nil, nil, fdirErr
-	}
-
-	baseFile := filepath.Base(filename)
-
-	return ParseFromString(s, fileDir, baseFile, relativeDir)
-}
-
-func ParseFromStdin() (map[string]parser.ParserValue, map[string]parser.ParserValue, error) {
-	b, err := readStdin()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	s := string(b)
-
-	cwd, cwdErr := os.Getwd()
-	if cwdErr != nil {
-		return nil, nil, cwdErr
-	}
-
-	absCwd, absCwdErr := filepath.Abs(cwd)
-	if absCwdErr != nil {
-		return nil, nil, absCwdErr
+		path, err = parser.ParsePath(path[0])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return ParseFromString(s, absCwd, "", cwd)
+	return parser.LookupPath(result.Object(), path)
 }
 
-func readStdin() ([]byte, error) {
-	b, err := io.ReadAll(os.Stdin)
+func check(err error) {
 	if err != nil {
-		return nil, err
+		fmt.Println(err)
+		os.Exit(1)
 	}
-
-	return b, nil
 }
 
 type options struct {
@@ -93,27 +41,55 @@ type options struct {
 	ToJson            bool
 	ShowConstants     bool
 	EnvFile           string
+	NoEnv             bool
+	JSONIndent        int
+	SortKeys          bool
+	ASCII             bool
+	NDJSON            bool
+	Export            string
+	Flatten           mconf.FlattenOptions
 }
 
 func usage(progname string) string {
 	return fmt.Sprintf(`Usage:
   %s <filename> [-- property1 property2 ...]
+  %s <command> [arguments...]
+
+Commands:
+  fmt                           Format mconf files, see '%s fmt --help'
+  set, del                      Change or remove a single value of a file in place, see '%s set --help'
+  diff                          Compare two evaluated files, see '%s diff --help'
+  merge                         Deep merge evaluated files into one, see '%s merge --help'
+  convert                       Convert between mconf, JSON, YAML and TOML, see '%s convert --help'
+  exec                          Run a command with the values of a file as environment variables, see '%s exec --help'
+  watch                         Evaluate a file again whenever it or its imports change, see '%s watch --help'
 
 Arguments:
   <filename>                    Path to the configuration file. Use '-' to read from stdin.
-  [-- property1 property2 ...]  List of properties to access. Multiple properties are used to access nested objects or lists. If no properties are provided, the global object is printed. '--' is simply there for readability.
+  [-- property1 property2 ...]  List of properties to access. Multiple properties are used to access nested objects or lists, one key or index each. A single property can also be a whole path, like servers[2].tls.cert (quote keys containing dots: '"a.b".c'). If no properties are provided, the global object is printed. '--' is simply there for readability.
 
 Options:
-  -h, --help        Show this message
-  -v, --version     Show version
-  -j, --json        Output as JSON (in a compact format, prettyfication is up to the user)
-  -d, --dotenv      Load .env file in current directory
-  --envfile <file>  Load specified enviorment variables file
-  -c, --constants   Show constants (only displayed when no properties are provided)
+  -h, --help          Show this message
+  -v, --version       Show version
+  -j, --json          Output as JSON (compact unless --indent is given)
+  --indent <n>        Indent JSON output with n spaces (implies --json)
+  --sort-keys         Sort the keys of objects in JSON output (implies --json)
+  --ascii             Escape every non-ASCII character in JSON output (implies --json)
+  --ndjson            Output a list as newline delimited JSON, one element per line (implies --json)
+  --export <format>   Output as environment variables: bash, zsh, fish (lines to source) or dotenv (a .env file)
+  --prefix <prefix>   Put prefix in front of every exported variable name
+  --case <case>       Case of exported variable names: upper (default), lower or preserve
+  --separator <sep>   Join the keys of nested values with sep in exported variable names (default '_')
+  --lists <mode>      Export lists as indexed variables (default, TAGS_0, TAGS_1...) or json (a single variable)
+  -d, --dotenv        Load .env file in current directory
+  --envfile <file>    Load specified enviorment variables file
+  --no-env            Don't fall back to environment variables for undefined constants (variables from --envfile and --dotenv are still used)
+  -c, --constants     Show constants (only displayed when no properties are provided)
 
 Examples:
   %s config.mconf -- property1 property2
-  cat config.mconf | %s - -- property1 property2`, progname, progname, progname)
+  %s config.mconf -- property1.property2[0]
+  cat config.mconf | %s - -- property1 property2`, progname, progname, progname, progname, progname, progname, progname, progname, progname, progname, progname, progname)
 }
 
 func version() string {
@@ -173,6 +149,30 @@ func parseOptions() (options, string, uint) {
 					opts.ShowConstants = true
 				} else if arg == "--dotenv" {
 					opts.EnvFile = ".env"
+				} else if arg == "--no-env" {
+					opts.NoEnv = true
+				} else if arg == "--sort-keys" {
+					opts.ToJson = true
+					opts.SortKeys = true
+				} else if arg == "--ascii" {
+					opts.ToJson = true
+					opts.ASCII = true
+				} else if arg == "--ndjson" {
+					opts.ToJson = true
+					opts.NDJSON = true
+				} else if arg == "--indent" {
+					if i+1 >= len(args) {
+						return opts, "No argument provided for --indent", 1
+					}
+
+					indent, err := strconv.Atoi(args[i+1])
+					if err != nil || indent < 0 {
+						return opts, fmt.Sprintf("Invalid indent `%s`, expected a number of spaces", args[i+1]), 1
+					}
+
+					opts.ToJson = true
+					opts.JSONIndent = indent
+					i++
 				} else if arg == "--envfile" {
 					if i+1 >= len(args) {
 						return opts, "No argument provided for --envfile", 1
@@ -180,6 +180,25 @@ func parseOptions() (options, string, uint) {
 						opts.EnvFile = args[i+1]
 						i++
 					}
+				} else if arg == "--export" || arg == "--prefix" || arg == "--case" || arg == "--separator" || arg == "--lists" {
+					if i+1 >= len(args) {
+						return opts, fmt.Sprintf("No argument provided for %s", arg), 1
+					}
+
+					switch arg {
+					case "--export":
+						opts.Export = args[i+1]
+					case "--prefix":
+						opts.Flatten.Prefix = args[i+1]
+					case "--case":
+						opts.Flatten.Case = args[i+1]
+					case "--separator":
+						opts.Flatten.Separator = args[i+1]
+					default:
+						opts.Flatten.Lists = args[i+1]
+					}
+
+					i++
 				}
 			} else {
 				for _, c := range arg[1:] {
@@ -213,130 +232,143 @@ func parseOptions() (options, string, uint) {
 		return opts, "No filename provided", 1
 	}
 
+	if opts.Export != "" && opts.ToJson {
+		return opts, "--export can't be combined with JSON output", 1
+	}
+
 	return opts, "", 0
 }
 
-func main() {
-	opts, usage, exitcode := parseOptions()
-
-	if usage != "" {
-		fmt.Println(usage)
-		os.Exit(int(exitcode))
+func readEnvFile(filename string, env map[string]string) error {
+	envFile, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("Error reading environment file %s", filename)
 	}
 
-	var globalObj map[string]parser.ParserValue
-	var constants map[string]parser.ParserValue
-	var parsingErr error
+	envFileStr := string(envFile)
+	envLines := strings.Split(envFileStr, "\n")
 
-	if opts.EnvFile != "" {
-		err := os.Setenv("MCONF_ENV_FILE", opts.EnvFile)
-		if err != nil {
-			fmt.Println("Error setting environment variable MCONF_ENV_FILE")
-			os.Exit(1)
+	for _, line := range envLines {
+		if line == "" {
+			continue
 		}
 
-		envFile, err := os.ReadFile(opts.EnvFile)
-		if err != nil {
-			fmt.Printf("Error reading environment file %s\n", opts.EnvFile)
-			os.Exit(1)
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 {
+			return fmt.Errorf("Error parsing environment file %s", filename)
 		}
 
-		envFileStr := string(envFile)
-		envLines := strings.Split(envFileStr, "\n")
+		if parts[0] == "" {
+			return fmt.Errorf("Error setting environment variable (%s)", line)
+		}
 
-		for _, line := range envLines {
-			if line == "" {
-				continue
-			}
+		if len(parts[1]) >= 2 && parts[1][0] == '"' && parts[1][len(parts[1])-1] == '"' {
+			parts[1] = unescapeEnvValue(parts[1][1 : len(parts[1])-1])
+		}
 
-			parts := strings.SplitN(line, "=", 2)
+		env[parts[0]] = parts[1]
+	}
 
-			if len(parts) != 2 {
-				fmt.Printf("Error parsing environment file %s\n", opts.EnvFile)
-				os.Exit(1)
-			}
+	return nil
+}
 
-			if parts[0] == "" {
-				fmt.Printf("Error setting environment variable (%s)\n", line)
-				os.Exit(1)
-			}
+// unescapeEnvValue undoes the escapes of a double quoted .env value in a single pass, so that `\\n` stays a backslash and an n
+func unescapeEnvValue(s string) string {
+	replacer := strings.NewReplacer("\\n", "\n", "\\r", "\r", "\\t", "\t", "\\\"", "\"", "\\$", "$", "\\`", "`", "\\\\", "\\")
+	return replacer.Replace(s)
+}
 
-			if parts[1][0] == '"' && parts[1][len(parts[1])-1] == '"' {
-				parts[1] = parts[1][1 : len(parts[1])-1]
-				parts[1] = strings.ReplaceAll(parts[1], "\\n", "\n")
-				parts[1] = strings.ReplaceAll(parts[1], "\\r", "\r")
-				parts[1] = strings.ReplaceAll(parts[1], "\\t", "\t")
-				parts[1] = strings.ReplaceAll(parts[1], "\\\"", "\"")
-				parts[1] = strings.ReplaceAll(parts[1], "\\\\", "\\")
-			}
+// environment builds the environment constants fall back to, it is the process environment with the env file laid over it
+func environment(opts options) (map[string]string, error) {
+	env := make(map[string]string)
 
-			err := os.Setenv(parts[0], parts[1])
-			if err != nil {
-				fmt.Printf("Error setting environment variable (%s)\n", line)
-				os.Exit(1)
-			}
+	if !opts.NoEnv {
+		for _, e := range os.Environ() {
+			pair := strings.SplitN(e, "=", 2)
+			env[pair[0]] = pair[1]
 		}
 	}
 
-	if opts.Filename == "-" {
-		globalObj, constants, parsingErr = ParseFromStdin()
-	} else {
-		globalObj, constants, parsingErr = ParseFromFile(opts.Filename)
+	if opts.EnvFile != "" {
+		env["MCONF_ENV_FILE"] = opts.EnvFile
+
+		err := readEnvFile(opts.EnvFile, env)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	check(parsingErr)
+	return env, nil
+}
 
-	var indexedValue parser.ParserValue = &parser.ParserValueObject{Value: globalObj}
+// runCommand runs a subcommand like `mconf fmt` when the first argument names one
+func runCommand() (int, bool) {
+	if len(os.Args) < 2 {
+		return 0, false
+	}
 
-	indexedString := ""
+	binname := filepath.Base(os.Args[0])
+	args := os.Args[2:]
+
+	switch os.Args[1] {
+	case "fmt":
+		return runFmt(binname, args), true
+	case "set", "del":
+		return runEdit(binname, os.Args[1], args), true
+	case "diff":
+		return runDiff(binname, args), true
+	case "merge":
+		return runMerge(binname, args), true
+	case "convert":
+		return runConvert(binname, args), true
+	case "exec":
+		return runExec(binname, args), true
+	case "watch":
+		return runWatch(binname, args), true
+	default:
+		return 0, false
+	}
+}
 
-	for _, p := range opts.AcessedProperties {
-		if indexedString == "" {
-			indexedString = p
-		} else {
-			indexedString += "." + p
-		}
+func main() {
+	if exitcode, ok := runCommand(); ok {
+		os.Exit(exitcode)
+	}
 
-		if indexedValue.GetType() != parser.PARSER_VALUE_TYPE_OBJECT && indexedValue.GetType() != parser.PARSER_VALUE_TYPE_LIST {
-			fmt.Printf("Property %s not found, indexed value is not an object or list\n", indexedString)
-			os.Exit(1)
-		}
+	opts, usage, exitcode := parseOptions()
 
-		if indexedValue.GetType() == parser.PARSER_VALUE_TYPE_OBJECT {
-			obj, err := indexedValue.GetObject()
-			if err != nil {
-				fmt.Printf("Unexpected error, indexed value has type object but cannot be converted to object, please report this bug\n")
-				os.Exit(1)
-			}
+	if usage != "" {
+		fmt.Println(usage)
+		os.Exit(int(exitcode))
+	}
 
-			next := obj[p]
+	var result *mconf.Result
+	var parsingErr error
 
-			if next == nil {
-				fmt.Printf("Property %s not found\n", indexedString)
-				os.Exit(1)
-			}
+	env, err := environment(opts)
+	check(err)
 
-			indexedValue = next
-		} else {
-			list, err := indexedValue.GetList()
-			if err != nil {
-				fmt.Printf("Unexpected error, indexed value has type list but cannot be converted to list, please report this bug\n")
-				os.Exit(1)
-			}
+	if opts.Filename == "-" {
+		result, parsingErr = mconf.ParseReader(os.Stdin, mconf.WithEnv(env))
+	} else {
+		result, parsingErr = mconf.Load(opts.Filename, mconf.WithEnv(env))
+	}
 
-			index, err := strconv.Atoi(p)
-			if err != nil {
-				fmt.Printf("Property %s not found, index is not an integer\n", indexedString)
-				os.Exit(1)
-			}
+	check(parsingErr)
 
-			if index < 0 || index >= len(list) {
-				fmt.Printf("Property %s not found, index out of bounds\n", indexedString)
-				os.Exit(1)
-			}
+	indexedValue, err := lookupProperties(result, opts.AcessedProperties)
+	check(err)
 
-			indexedValue = list[index]
-		}
+	if opts.Export != "" {
+		vars, err := mconf.Flatten(indexedValue, opts.Flatten)
+		check(err)
+
+		out, err := mconf.Export(vars, opts.Export)
+		check(err)
+
+		fmt.Print(out)
+		return
 	}
 
 	if opts.ToJson {
@@ -344,7 +376,19 @@ func main() {
 			fmt.Printf("Displaying constants is not supported when outputting as JSON\n")
 			os.Exit(1)
 		}
-		fmt.Println(indexedValue.ToJSONString())
+
+		encoder := parser.NewJSONEncoder(os.Stdout, parser.JSONOptions{
+			Indent:   opts.JSONIndent,
+			SortKeys: opts.SortKeys,
+			ASCII:    opts.ASCII,
+		})
+
+		if opts.NDJSON {
+			check(encoder.EncodeLines(indexedValue))
+		} else {
+			check(encoder.Encode(indexedValue))
+		}
+
 		return
 	}
 
@@ -361,7 +405,9 @@ func main() {
 		fmt.Println(indexedValue.ValueToString(2))
 
 		if len(opts.AcessedProperties) == 0 && opts.ShowConstants {
-			for k, v := range constants {
+			for _, k := range result.Constants.Keys() {
+				v, _ := result.Constants.Get(k)
+
 				fmt.Printf("$%s = %s\n", k, v.ValueToString(2))
 			}
 		}
@@ -0,0 +1,383 @@
+package mconf
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+// DecodeError reports a value that could not be stored in the Go value it was decoded into
+type DecodeError struct {
+	Path    string
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("mconf: cannot decode: %s", e.Message)
+	}
+
+	return fmt.Sprintf("mconf: cannot decode %s: %s", e.Path, e.Message)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	parserValueType     = reflect.TypeOf((*parser.ParserValue)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	bigIntType          = reflect.TypeOf(big.Int{})
+	bigFloatType        = reflect.TypeOf(big.Float{})
+)
+
+// Unmarshal parses data and stores the resulting values in the struct or map pointed to by v
+func Unmarshal(data []byte, v any, opts ...Option) error {
+	result, err := Parse(data, opts...)
+	if err != nil {
+		return err
+	}
+
+	return Decode(result.Object(), v)
+}
+
+// Decode stores an already evaluated value in the Go value pointed to by v
+func Decode(value parser.ParserValue, v any) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &DecodeError{Message: fmt.Sprintf("expected a non-nil pointer, got %T", v)}
+	}
+
+	return decodeValue(value, rv.Elem(), "")
+}
+
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func joinIndex(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+func mismatch(path string, value parser.ParserValue, target reflect.Type) error {
+	return &DecodeError{Path: path, Message: fmt.Sprintf("cannot store %s in a value of type %s", value.GetType(), target)}
+}
+
+func decodeValue(value parser.ParserValue, rv reflect.Value, path string) error {
+	if rv.Type() == parserValueType {
+		rv.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	if value.IsNull() {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		return decodeValue(value, rv.Elem(), path)
+	}
+
+	if rv.Type() == durationType {
+		return decodeDuration(value, rv, path)
+	}
+
+	if rv.Type() == bigIntType {
+		i, err := value.GetInt()
+		if err != nil || value.GetType() != parser.PARSER_VALUE_TYPE_INT {
+			return mismatch(path, value, rv.Type())
+		}
+
+		rv.Set(reflect.ValueOf(*new(big.Int).Set(i)))
+		return nil
+	}
+
+	if rv.Type() == bigFloatType {
+		f, err := value.GetFloat()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		rv.Set(reflect.ValueOf(*new(big.Float).Set(f)))
+		return nil
+	}
+
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		s, err := value.GetString()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		err = rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		if err != nil {
+			return &DecodeError{Path: path, Message: err.Error()}
+		}
+
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return mismatch(path, value, rv.Type())
+		}
+
+		native, err := toNative(value, NativeOptions{}, path)
+		if err != nil {
+			return err
+		}
+
+		if native == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+		} else {
+			rv.Set(reflect.ValueOf(native))
+		}
+	case reflect.Struct:
+		return decodeStruct(value, rv, path)
+	case reflect.Map:
+		return decodeMap(value, rv, path)
+	case reflect.Slice:
+		list, err := value.GetList()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		slice := reflect.MakeSlice(rv.Type(), len(list), len(list))
+
+		for i, item := range list {
+			err := decodeValue(item, slice.Index(i), joinIndex(path, i))
+			if err != nil {
+				return err
+			}
+		}
+
+		rv.Set(slice)
+	case reflect.Array:
+		list, err := value.GetList()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		if len(list) != rv.Len() {
+			return &DecodeError{Path: path, Message: fmt.Sprintf("list has %d elements, but %s holds exactly %d", len(list), rv.Type(), rv.Len())}
+		}
+
+		for i, item := range list {
+			err := decodeValue(item, rv.Index(i), joinIndex(path, i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		s, err := value.GetString()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := value.GetBool()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := integerValue(value, path, rv.Type())
+		if err != nil {
+			return err
+		}
+
+		if !i.IsInt64() || rv.OverflowInt(i.Int64()) {
+			return &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows %s", i.String(), rv.Type())}
+		}
+
+		rv.SetInt(i.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := integerValue(value, path, rv.Type())
+		if err != nil {
+			return err
+		}
+
+		if i.Sign() < 0 || !i.IsUint64() || rv.OverflowUint(i.Uint64()) {
+			return &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows %s", i.String(), rv.Type())}
+		}
+
+		rv.SetUint(i.Uint64())
+	case reflect.Float32, reflect.Float64:
+		f, err := value.GetFloat()
+		if err != nil {
+			return mismatch(path, value, rv.Type())
+		}
+
+		f64, _ := f.Float64()
+		if math.IsInf(f64, 0) || rv.OverflowFloat(f64) {
+			return &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows %s", f.String(), rv.Type())}
+		}
+
+		rv.SetFloat(f64)
+	default:
+		return &DecodeError{Path: path, Message: fmt.Sprintf("unsupported type %s", rv.Type())}
+	}
+
+	return nil
+}
+
+// integerValue accepts ints as well as floats without a fractional part
+func integerValue(value parser.ParserValue, path string, target reflect.Type) (*big.Int, error) {
+	switch value.GetType() {
+	case parser.PARSER_VALUE_TYPE_INT:
+		return value.GetInt()
+	case parser.PARSER_VALUE_TYPE_FLOAT:
+		f, err := value.GetFloat()
+		if err != nil {
+			return nil, err
+		}
+
+		if !f.IsInt() {
+			return nil, &DecodeError{Path: path, Message: fmt.Sprintf("value %s is not a whole number and cannot be stored in %s", f.String(), target)}
+		}
+
+		i, _ := f.Int(nil)
+		return i, nil
+	default:
+		return nil, mismatch(path, value, target)
+	}
+}
+
+func decodeDuration(value parser.ParserValue, rv reflect.Value, path string) error {
+	switch value.GetType() {
+	case parser.PARSER_VALUE_TYPE_STRING:
+		s, err := value.GetString()
+		if err != nil {
+			return err
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &DecodeError{Path: path, Message: err.Error()}
+		}
+
+		rv.SetInt(int64(d))
+	case parser.PARSER_VALUE_TYPE_INT:
+		i, err := value.GetInt()
+		if err != nil {
+			return err
+		}
+
+		if !i.IsInt64() {
+			return &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows %s", i.String(), rv.Type())}
+		}
+
+		rv.SetInt(i.Int64())
+	default:
+		return mismatch(path, value, rv.Type())
+	}
+
+	return nil
+}
+
+func decodeStruct(value parser.ParserValue, rv reflect.Value, path string) error {
+	obj, err := value.GetObject()
+	if err != nil {
+		return mismatch(path, value, rv.Type())
+	}
+
+	fields := structFields(rv.Type())
+
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+
+		f, ok := fieldByName(fields, k)
+		if !ok {
+			continue
+		}
+
+		fv, err := fieldByIndexAlloc(rv, f.index)
+		if err != nil {
+			return &DecodeError{Path: joinKey(path, k), Message: err.Error()}
+		}
+
+		err = decodeValue(v, fv, joinKey(path, k))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex, except that nil embedded pointers are allocated on the way
+func fieldByIndexAlloc(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(x)
+	}
+
+	return rv, nil
+}
+
+func decodeMap(value parser.ParserValue, rv reflect.Value, path string) error {
+	obj, err := value.GetObject()
+	if err != nil {
+		return mismatch(path, value, rv.Type())
+	}
+
+	keyType := rv.Type().Key()
+
+	if keyType.Kind() != reflect.String && !reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		return &DecodeError{Path: path, Message: fmt.Sprintf("unsupported map key type %s", keyType)}
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), obj.Len()))
+	}
+
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+
+		elem := reflect.New(rv.Type().Elem()).Elem()
+
+		err := decodeValue(v, elem, joinKey(path, k))
+		if err != nil {
+			return err
+		}
+
+		key := reflect.New(keyType).Elem()
+
+		if keyType.Kind() == reflect.String {
+			key.SetString(k)
+		} else {
+			err := key.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k))
+			if err != nil {
+				return &DecodeError{Path: joinKey(path, k), Message: err.Error()}
+			}
+		}
+
+		rv.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
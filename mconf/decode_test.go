@@ -0,0 +1,95 @@
+package mconf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeServer struct {
+	Host    string        `mconf:"host"`
+	Port    uint16        `mconf:"port"`
+	Timeout time.Duration `mconf:"timeout"`
+	Tags    []string      `mconf:"tags,omitempty"`
+	Ignored string        `mconf:"-"`
+	Weight  float32
+}
+
+type decodeConfig struct {
+	Name    string            `mconf:"name"`
+	Servers []decodeServer    `mconf:"servers"`
+	Labels  map[string]string `mconf:"labels"`
+	Limit   *int              `mconf:"limit"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	src := `
+		name = "app"
+		servers = [
+			{ host = "a", port = 80, timeout = "5s", tags = ["x"], Ignored = "no", weight = 0.5 },
+			{ host = "b", port = 8080.0, timeout = 1000 },
+		]
+		labels = { env = "prod" }
+		limit = 3
+	`
+
+	var cfg decodeConfig
+	if err := Unmarshal([]byte(src), &cfg, WithoutEnv()); err != nil {
+		t.Fatal(err)
+	}
+
+	limit := 3
+	expected := decodeConfig{
+		Name: "app",
+		Servers: []decodeServer{
+			{Host: "a", Port: 80, Timeout: 5 * time.Second, Tags: []string{"x"}, Weight: 0.5},
+			{Host: "b", Port: 8080, Timeout: 1000},
+		},
+		Labels: map[string]string{"env": "prod"},
+		Limit:  &limit,
+	}
+
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("decoded %+v, expected %+v", cfg, expected)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		target  any
+		path    string
+		message string
+	}{
+		{"uint overflow", "servers = [{ port = 70000 }]", &decodeConfig{}, "servers[0].port", "overflows uint16"},
+		{"negative uint", "servers = [{ port = -1 }]", &decodeConfig{}, "servers[0].port", "overflows uint16"},
+		{"int overflow", "limit = 9223372036854775808", &decodeConfig{}, "limit", "overflows int"},
+		{"float overflow", "servers = [{ weight = 1.0e39 }]", &decodeConfig{}, "servers[0].weight", "overflows float32"},
+		{"fraction", "servers = [{ port = 80.5 }]", &decodeConfig{}, "servers[0].port", "not a whole number"},
+		{"string for int", `limit = "3"`, &decodeConfig{}, "limit", "cannot store STRING"},
+		{"object for list", "servers = { host = \"a\" }", &decodeConfig{}, "servers", "cannot store OBJECT"},
+		{"bad duration", `servers = [{ timeout = "soon" }]`, &decodeConfig{}, "servers[0].timeout", "invalid duration"},
+		{"array length", "a = [1, 2, 3]", &struct {
+			A [2]int `mconf:"a"`
+		}{}, "a", "holds exactly 2"},
+		{"not a pointer", "a = 1", decodeConfig{}, "", "expected a non-nil pointer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.src), tt.target, WithoutEnv())
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected a *DecodeError, got %v", err)
+			}
+
+			if decodeErr.Path != tt.path || !strings.Contains(decodeErr.Message, tt.message) {
+				t.Errorf("got %q at %q, expected %q at %q", decodeErr.Message, decodeErr.Path, tt.message, tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package mconf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+const (
+	CHANGE_TYPE_ADDED   = "ADDED"
+	CHANGE_TYPE_REMOVED = "REMOVED"
+	CHANGE_TYPE_CHANGED = "CHANGED"
+)
+
+// Change is one difference found by Diff
+type Change struct {
+	Type string
+	// Path is where the change is, printed like `servers[1].port`, and Segments the same path split up (list indexes as numbers).
+	// indexes of removed list elements are their indexes in the old list, all other indexes are from the new one
+	Path     string
+	Segments []string
+	// Old is nil for added values and New is nil for removed ones
+	Old parser.ParserValue
+	New parser.ParserValue
+
+	// patchPath is the path as JSON Patch needs it, where list indexes count the changes applied before this one
+	patchPath []string
+}
+
+type differ struct {
+	changes []Change
+}
+
+// Diff compares two evaluated values and returns what changed between them, descending into objects and lists.
+// list elements are matched up so that an element inserted or removed in the middle shows up as a single change
+func Diff(a parser.ParserValue, b parser.ParserValue) []Change {
+	d := differ{}
+	d.diff(a, b, "", []string{}, []string{})
+
+	return d.changes
+}
+
+func (d *differ) add(changeType string, path string, segments []string, patchPath []string, oldValue parser.ParserValue, newValue parser.ParserValue) {
+	d.changes = append(d.changes, Change{
+		Type:      changeType,
+		Path:      path,
+		Segments:  segments,
+		Old:       oldValue,
+		New:       newValue,
+		patchPath: patchPath,
+	})
+}
+
+// with returns a copy of path with seg added, so that changes never share their backing arrays
+func with(path []string, seg string) []string {
+	return append(append([]string{}, path...), seg)
+}
+
+func (d *differ) diff(a parser.ParserValue, b parser.ParserValue, path string, segments []string, patchPath []string) {
+	if parser.Equal(a, b) {
+		return
+	}
+
+	switch {
+	case a.GetType() == parser.PARSER_VALUE_TYPE_OBJECT && b.GetType() == parser.PARSER_VALUE_TYPE_OBJECT:
+		ao, _ := a.GetObject()
+		bo, _ := b.GetObject()
+
+		d.diffObjects(ao, bo, path, segments, patchPath)
+	case a.GetType() == parser.PARSER_VALUE_TYPE_LIST && b.GetType() == parser.PARSER_VALUE_TYPE_LIST:
+		al, _ := a.GetList()
+		bl, _ := b.GetList()
+
+		d.diffLists(al, bl, path, segments, patchPath)
+	default:
+		d.add(CHANGE_TYPE_CHANGED, path, segments, patchPath, a, b)
+	}
+}
+
+func (d *differ) diffObjects(a *parser.OrderedMap, b *parser.OrderedMap, path string, segments []string, patchPath []string) {
+	for _, k := range a.Keys() {
+		av, _ := a.Get(k)
+		keyPath := parser.AppendPathKey(path, k)
+
+		bv, ok := b.Get(k)
+		if !ok {
+			d.add(CHANGE_TYPE_REMOVED, keyPath, with(segments, k), with(patchPath, k), av, nil)
+			continue
+		}
+
+		d.diff(av, bv, keyPath, with(segments, k), with(patchPath, k))
+	}
+
+	for _, k := range b.Keys() {
+		if a.Has(k) {
+			continue
+		}
+
+		bv, _ := b.Get(k)
+		d.add(CHANGE_TYPE_ADDED, parser.AppendPathKey(path, k), with(segments, k), with(patchPath, k), nil, bv)
+	}
+}
+
+func (d *differ) diffLists(a []parser.ParserValue, b []parser.ParserValue, path string, segments []string, patchPath []string) {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if parser.Equal(a[i], b[j]) {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	index := func(i int) string {
+		return fmt.Sprintf("%s[%d]", path, i)
+	}
+
+	// position is where the current element is in the list as it is being patched
+	i, j, position := 0, 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && parser.Equal(a[i], b[j]):
+			i++
+			j++
+			position++
+		case i < len(a) && j < len(b) && common[i][j] == common[i+1][j+1]:
+			// neither element is kept, so the one was replaced by the other
+			d.diff(a[i], b[j], index(j), with(segments, fmt.Sprint(j)), with(patchPath, fmt.Sprint(position)))
+			i++
+			j++
+			position++
+		case j < len(b) && (i == len(a) || common[i][j+1] >= common[i+1][j]):
+			d.add(CHANGE_TYPE_ADDED, index(j), with(segments, fmt.Sprint(j)), with(patchPath, fmt.Sprint(position)), nil, b[j])
+			j++
+			position++
+		default:
+			d.add(CHANGE_TYPE_REMOVED, index(i), with(segments, fmt.Sprint(i)), with(patchPath, fmt.Sprint(position)), a[i], nil)
+			i++
+		}
+	}
+}
+
+// Pointer returns the JSON Pointer (RFC 6901) of the change as used by ChangesToPatch
+func (c Change) Pointer() string {
+	sb := strings.Builder{}
+
+	for _, seg := range c.patchPath {
+		sb.WriteString("/")
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(seg, "~", "~0"), "/", "~1"))
+	}
+
+	return sb.String()
+}
+
+// ChangesToValue turns changes into a list of objects with a type, a path and the old and new values, ready to be encoded as JSON
+func ChangesToValue(changes []Change) parser.ParserValue {
+	list := []parser.ParserValue{}
+
+	for _, c := range changes {
+		obj := parser.NewOrderedMap()
+		obj.Set("type", &parser.ParserValueString{Value: c.Type})
+		obj.Set("path", &parser.ParserValueString{Value: c.Path})
+
+		if c.Old != nil {
+			obj.Set("old", c.Old)
+		}
+
+		if c.New != nil {
+			obj.Set("new", c.New)
+		}
+
+		list = append(list, &parser.ParserValueObject{Value: obj})
+	}
+
+	return &parser.ParserValueList{Value: list}
+}
+
+// ChangesToPatch turns changes into a JSON Patch (RFC 6902) document that turns the old value into the new one
+func ChangesToPatch(changes []Change) parser.ParserValue {
+	list := []parser.ParserValue{}
+
+	for _, c := range changes {
+		obj := parser.NewOrderedMap()
+
+		switch c.Type {
+		case CHANGE_TYPE_ADDED:
+			obj.Set("op", &parser.ParserValueString{Value: "add"})
+		case CHANGE_TYPE_REMOVED:
+			obj.Set("op", &parser.ParserValueString{Value: "remove"})
+		default:
+			obj.Set("op", &parser.ParserValueString{Value: "replace"})
+		}
+
+		obj.Set("path", &parser.ParserValueString{Value: c.Pointer()})
+
+		if c.New != nil {
+			obj.Set("value", c.New)
+		}
+
+		list = append(list, &parser.ParserValueObject{Value: obj})
+	}
+
+	return &parser.ParserValueList{Value: list}
+}
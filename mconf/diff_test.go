@@ -0,0 +1,163 @@
+package mconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func parseObject(t *testing.T, src string) parser.ParserValue {
+	t.Helper()
+
+	result, err := Parse([]byte(src), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result.Object()
+}
+
+// describeChanges prints changes one per line, like `mconf diff` does
+func describeChanges(changes []Change) string {
+	lines := []string{}
+
+	for _, c := range changes {
+		switch c.Type {
+		case CHANGE_TYPE_ADDED:
+			lines = append(lines, fmt.Sprintf("+ %s = %s", c.Path, c.New.ValueToString()))
+		case CHANGE_TYPE_REMOVED:
+			lines = append(lines, fmt.Sprintf("- %s = %s", c.Path, c.Old.ValueToString()))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s = %s -> %s", c.Path, c.Old.ValueToString(), c.New.ValueToString()))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var diffTests = []struct {
+	name     string
+	old      string
+	new      string
+	expected string
+}{
+	{"equal", "a = 1\nb = [1, 2]", "a = 1\nb = [1, 2]", ""},
+	{"changed", "a = 1", "a = 2", "~ a = 1 -> 2"},
+	{"type changed", "a = 1", "a = 1.0", "~ a = 1 -> 1.0"},
+	{"added and removed keys", "a = 1\nb = 2", "b = 2\nc = 3", "- a = 1\n+ c = 3"},
+	{"nested", "db = { host = \"a\", port = 1 }", "db = { host = \"b\", port = 1 }", "~ db.host = \"a\" -> \"b\""},
+	{"inserted in list", "l = [1, 2, 3]", "l = [1, 9, 2, 3]", "+ l[1] = 9"},
+	{"removed from list", "l = [1, 2, 3]", "l = [1, 3]", "- l[1] = 2"},
+	{"replaced in list", "l = [1, 2, 3]", "l = [1, 5, 3]", "~ l[1] = 2 -> 5"},
+	{"object in list", "l = [{ a = 1 }, { a = 2 }]", "l = [{ a = 1 }, { a = 3 }]", "~ l[1].a = 2 -> 3"},
+	{"several list changes", "l = [1, 2, 3, 4, 5]", "l = [0, 1, 3, 4, 6]", "+ l[0] = 0\n- l[1] = 2\n~ l[4] = 5 -> 6"},
+	{"keys needing quotes", "\"a.b\" = 1", "\"a.b\" = 2\n\"x/y~\" = 3", "~ \"a.b\" = 1 -> 2\n+ \"x/y~\" = 3"},
+}
+
+func TestDiff(t *testing.T) {
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(parseObject(t, tt.old), parseObject(t, tt.new))
+
+			if got := describeChanges(changes); got != tt.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestChangesToPatch(t *testing.T) {
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := parseObject(t, tt.old), parseObject(t, tt.new)
+
+			doc, err := ToNative(before, NativeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patch, err := ToNative(ChangesToPatch(Diff(before, after)), NativeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, op := range patch.([]any) {
+				doc = applyPatchOperation(t, doc, op.(map[string]any))
+			}
+
+			expected, err := ToNative(after, NativeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(doc, expected) {
+				t.Errorf("applying the patch gave %v, expected %v", doc, expected)
+			}
+		})
+	}
+}
+
+func TestChangePointer(t *testing.T) {
+	changes := Diff(parseObject(t, "\"a/b\" = { \"~c\" = [1] }"), parseObject(t, "\"a/b\" = { \"~c\" = [2] }"))
+
+	if len(changes) != 1 || changes[0].Pointer() != "/a~1b/~0c/0" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+// applyPatchOperation applies a single add, remove or replace operation of a JSON Patch to doc
+func applyPatchOperation(t *testing.T, doc any, op map[string]any) any {
+	t.Helper()
+
+	segments := strings.Split(op["path"].(string), "/")[1:]
+
+	for i, seg := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+	}
+
+	var apply func(parent any, segments []string) any
+
+	apply = func(parent any, segments []string) any {
+		last := len(segments) == 1
+
+		switch p := parent.(type) {
+		case map[string]any:
+			if !last {
+				p[segments[0]] = apply(p[segments[0]], segments[1:])
+			} else if op["op"] == "remove" {
+				delete(p, segments[0])
+			} else {
+				p[segments[0]] = op["value"]
+			}
+
+			return p
+		case []any:
+			i, err := strconv.Atoi(segments[0])
+			if err != nil {
+				t.Fatalf("invalid index %s", segments[0])
+			}
+
+			switch {
+			case !last:
+				p[i] = apply(p[i], segments[1:])
+				return p
+			case op["op"] == "add":
+				return append(p[:i], append([]any{op["value"]}, p[i:]...)...)
+			case op["op"] == "remove":
+				return append(p[:i], p[i+1:]...)
+			default:
+				p[i] = op["value"]
+				return p
+			}
+		default:
+			t.Fatalf("path %s goes through a %T", op["path"], parent)
+			return nil
+		}
+	}
+
+	return apply(doc, segments)
+}
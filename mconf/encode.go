@@ -0,0 +1,256 @@
+package mconf
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+// EncodeError reports a Go value that has no mconf representation
+type EncodeError struct {
+	Path    string
+	Message string
+}
+
+func (e *EncodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("mconf: cannot encode: %s", e.Message)
+	}
+
+	return fmt.Sprintf("mconf: cannot encode %s: %s", e.Path, e.Message)
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Marshal renders a struct or map as mconf source, using the same struct tags as Decode
+func Marshal(v any) ([]byte, error) {
+	value, err := encodeValue(reflect.ValueOf(v), "")
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := value.(*parser.ParserValueObject)
+	if !ok {
+		return nil, &EncodeError{Message: fmt.Sprintf("top-level value must be a struct or a map, got %T", v)}
+	}
+
+	return []byte(obj.TopLevelString(2)), nil
+}
+
+func encodeValue(rv reflect.Value, path string) (parser.ParserValue, error) {
+	if !rv.IsValid() {
+		return &parser.ParserValueNull{Value: true}, nil
+	}
+
+	if rv.Type().Implements(parserValueType) {
+		if rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return &parser.ParserValueNull{Value: true}, nil
+			}
+		}
+
+		return rv.Interface().(parser.ParserValue), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return &parser.ParserValueNull{Value: true}, nil
+		}
+
+		if rv.Kind() == reflect.Pointer && (rv.Type().Elem() == bigIntType || rv.Type().Elem() == bigFloatType) {
+			return encodeValue(rv.Elem(), path)
+		}
+
+		if rv.Type().Implements(textMarshalerType) {
+			return encodeText(rv, path)
+		}
+
+		return encodeValue(rv.Elem(), path)
+	}
+
+	switch rv.Type() {
+	case durationType:
+		return &parser.ParserValueString{Value: time.Duration(rv.Int()).String()}, nil
+	case bigIntType:
+		i := rv.Interface().(big.Int)
+		return &parser.ParserValueInt{Value: new(big.Int).Set(&i)}, nil
+	case bigFloatType:
+		f := rv.Interface().(big.Float)
+		if f.IsInf() {
+			return nil, &EncodeError{Path: path, Message: "infinite floats cannot be represented"}
+		}
+
+		return &parser.ParserValueFloat{Value: new(big.Float).Copy(&f)}, nil
+	}
+
+	if rv.Type().Implements(textMarshalerType) {
+		return encodeText(rv, path)
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return &parser.ParserValueString{Value: rv.String()}, nil
+	case reflect.Bool:
+		return &parser.ParserValueBool{Value: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &parser.ParserValueInt{Value: big.NewInt(rv.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &parser.ParserValueInt{Value: new(big.Int).SetUint64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &EncodeError{Path: path, Message: fmt.Sprintf("%v cannot be represented", f)}
+		}
+
+		return &parser.ParserValueFloat{Value: big.NewFloat(f)}, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return &parser.ParserValueNull{Value: true}, nil
+		}
+
+		list := make([]parser.ParserValue, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			item, err := encodeValue(rv.Index(i), joinIndex(path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = item
+		}
+
+		return &parser.ParserValueList{Value: list}, nil
+	case reflect.Map:
+		return encodeMap(rv, path)
+	case reflect.Struct:
+		return encodeStruct(rv, path)
+	default:
+		return nil, &EncodeError{Path: path, Message: fmt.Sprintf("unsupported type %s", rv.Type())}
+	}
+}
+
+func encodeText(rv reflect.Value, path string) (parser.ParserValue, error) {
+	text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, &EncodeError{Path: path, Message: err.Error()}
+	}
+
+	return &parser.ParserValueString{Value: string(text)}, nil
+}
+
+func encodeMap(rv reflect.Value, path string) (parser.ParserValue, error) {
+	if rv.IsNil() {
+		return &parser.ParserValueNull{Value: true}, nil
+	}
+
+	keyType := rv.Type().Key()
+
+	if keyType.Kind() != reflect.String && !keyType.Implements(textMarshalerType) {
+		return nil, &EncodeError{Path: path, Message: fmt.Sprintf("unsupported map key type %s", keyType)}
+	}
+
+	keys := make([]string, 0, rv.Len())
+	values := make(map[string]reflect.Value, rv.Len())
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		var key string
+
+		if keyType.Kind() == reflect.String {
+			key = iter.Key().String()
+		} else {
+			text, err := iter.Key().Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, &EncodeError{Path: path, Message: err.Error()}
+			}
+
+			key = string(text)
+		}
+
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+
+	// map iteration order is random, sorting keeps the output stable
+	sort.Strings(keys)
+
+	obj := parser.NewOrderedMap()
+
+	for _, k := range keys {
+		value, err := encodeValue(values[k], joinKey(path, k))
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(k, value)
+	}
+
+	return &parser.ParserValueObject{Value: obj}, nil
+}
+
+func encodeStruct(rv reflect.Value, path string) (parser.ParserValue, error) {
+	obj := parser.NewOrderedMap()
+
+	for _, f := range structFields(rv.Type()) {
+		fv, ok := fieldByIndexNoAlloc(rv, f.index)
+		if !ok {
+			continue
+		}
+
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		value, err := encodeValue(fv, joinKey(path, f.name))
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(f.name, value)
+	}
+
+	return &parser.ParserValueObject{Value: obj}, nil
+}
+
+// fieldByIndexNoAlloc is reflect.Value.FieldByIndex, except that it reports false when it runs into a nil embedded pointer
+func fieldByIndexNoAlloc(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(x)
+	}
+
+	return rv, true
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
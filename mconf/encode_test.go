@@ -0,0 +1,132 @@
+package mconf
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestMarshalFloatRoundTrip(t *testing.T) {
+	type floats struct {
+		Tenth float64 `mconf:"tenth"`
+		Small float64 `mconf:"small"`
+		Sum   float64 `mconf:"sum"`
+		Pi    float64 `mconf:"pi"`
+		Large float64 `mconf:"large"`
+		Max   float64 `mconf:"max"`
+	}
+
+	in := floats{0.1, 1e-7, 0.1 + 0.2, math.Pi, 1.5e300, math.MaxFloat64}
+
+	src, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Parse(src, WithoutEnv())
+	if err != nil {
+		t.Fatalf("unexpected error parsing %s: %v", src, err)
+	}
+
+	encoded, err := encodeValue(reflect.ValueOf(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parser.Equal(encoded, result.Object()) {
+		t.Errorf("parsed values differ from the encoded ones:\n%s", src)
+	}
+
+	var out floats
+	if err := Decode(result.Object(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out != in {
+		t.Errorf("decoded %+v, expected %+v", out, in)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type server struct {
+		Host string   `mconf:"host"`
+		Port int      `mconf:"port"`
+		Tags []string `mconf:"tags,omitempty"`
+		Skip string   `mconf:"-"`
+	}
+
+	out, err := Marshal(struct {
+		Name    string         `mconf:"name"`
+		Servers []server       `mconf:"servers"`
+		Labels  map[string]any `mconf:"labels"`
+		Timeout time.Duration  `mconf:"timeout"`
+	}{
+		Name:    "app",
+		Servers: []server{{Host: "a", Port: 80, Tags: []string{"x"}, Skip: "no"}, {Host: "b", Port: 81}},
+		Labels:  map[string]any{"b": nil, "a.b": true, "": 1.5},
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `name = "app"
+servers = [
+  {
+    host = "a"
+    port = 80
+    tags = ["x"]
+  },
+  {
+    host = "b"
+    port = 81
+  }
+]
+labels = {
+  "" = 1.5
+  "a.b" = true
+  b = null
+}
+timeout = "5s"`
+
+	if got := strings.TrimSpace(string(out)); got != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	if _, err := Parse(out, WithoutEnv()); err != nil {
+		t.Errorf("output doesn't parse: %v", err)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		path  string
+	}{
+		{"top-level list", []int{1}, ""},
+		{"nan", map[string]float64{"a": math.NaN()}, "a"},
+		{"channel", map[string]any{"a": map[string]any{"b": make(chan int)}}, "a.b"},
+		{"map key", map[string]any{"a": map[int]int{1: 1}}, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.value)
+
+			var encodeErr *EncodeError
+			if !errors.As(err, &encodeErr) {
+				t.Fatalf("expected an *EncodeError, got %v", err)
+			}
+
+			if encodeErr.Path != tt.path {
+				t.Errorf("error at %q, expected %q: %v", encodeErr.Path, tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package mconf
+
+import (
+	"github.com/marzeq/mconf/diagnostics"
+)
+
+// the error types reported while tokenising and parsing, re-exported so that errors.As works without importing diagnostics
+type (
+	Position              = diagnostics.Position
+	Diagnostic            = diagnostics.Diagnostic
+	SyntaxError           = diagnostics.SyntaxError
+	ImportError           = diagnostics.ImportError
+	ConstantNotFoundError = diagnostics.ConstantNotFoundError
+	TypeError             = diagnostics.TypeError
+	EvaluationError       = diagnostics.EvaluationError
+)
@@ -0,0 +1,99 @@
+package mconf
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/marzeq/mconf/diagnostics"
+)
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		code string
+		line int
+		col  int
+		as   func(err error) bool
+	}{
+		{"syntax", "a = \n}", diagnostics.CODE_UNEXPECTED_TOKEN, 2, 1, func(err error) bool {
+			var e *SyntaxError
+			return errors.As(err, &e)
+		}},
+		{"constant", "a = 1\nb = $missing", diagnostics.CODE_CONSTANT_NOT_FOUND, 2, 5, func(err error) bool {
+			var e *ConstantNotFoundError
+			return errors.As(err, &e) && e.Name == "missing"
+		}},
+		{"type", `a = 1 - "x"`, diagnostics.CODE_TYPE_MISMATCH, 1, 7, func(err error) bool {
+			var e *TypeError
+			return errors.As(err, &e)
+		}},
+		{"evaluation", "a = 1 / 0", diagnostics.CODE_DIVISION_BY_ZERO, 1, 7, func(err error) bool {
+			var e *EvaluationError
+			return errors.As(err, &e)
+		}},
+		{"import", `@import "missing.mconf"`, diagnostics.CODE_IMPORT_READ_FAILED, 1, 9, func(err error) bool {
+			var e *ImportError
+			return errors.As(err, &e) && e.Path == "missing.mconf" && e.Err != nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.src), WithDir(t.TempDir()), WithFilename("test.mconf"), WithoutEnv())
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+
+			if !tt.as(err) {
+				t.Fatalf("unexpected error type: %#v", err)
+			}
+
+			var list diagnostics.List
+			if !errors.As(err, &list) || len(list) != 1 {
+				t.Fatalf("expected a list with a single error, got %v", err)
+			}
+
+			diag := diagnosticOf(list[0])
+			if diag == nil {
+				t.Fatalf("%v doesn't carry a diagnostic", list[0])
+			}
+
+			if diag.Code != tt.code || filepath.Base(diag.File) != "test.mconf" || diag.Line != tt.line || diag.Col != tt.col {
+				t.Errorf("got %s at %s, expected %s at test.mconf:%d:%d", diag.Code, diag.Position, tt.code, tt.line, tt.col)
+			}
+		})
+	}
+}
+
+func TestErrorsAreCollected(t *testing.T) {
+	_, err := Parse([]byte("a = $one\nb = 1 / 0\nc = $two"), WithoutEnv())
+
+	var list diagnostics.List
+	if !errors.As(err, &list) {
+		t.Fatalf("expected a diagnostics.List, got %v", err)
+	}
+
+	if len(list) != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", len(list), err)
+	}
+}
+
+// diagnosticOf returns the Diagnostic embedded in one of the typed errors
+func diagnosticOf(err error) *Diagnostic {
+	switch e := err.(type) {
+	case *SyntaxError:
+		return &e.Diagnostic
+	case *ImportError:
+		return &e.Diagnostic
+	case *ConstantNotFoundError:
+		return &e.Diagnostic
+	case *TypeError:
+		return &e.Diagnostic
+	case *EvaluationError:
+		return &e.Diagnostic
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package mconf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	EXPORT_FORMAT_BASH   = "bash"
+	EXPORT_FORMAT_ZSH    = "zsh"
+	EXPORT_FORMAT_FISH   = "fish"
+	EXPORT_FORMAT_DOTENV = "dotenv"
+)
+
+var (
+	shellName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dotenvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	// dotenvPlain are the values a .env file can hold without quotes
+	dotenvPlain = regexp.MustCompile(`^[A-Za-z0-9_./:@,+-]*$`)
+)
+
+// Export renders variables as lines a shell can source (`export NAME='value'`, `set -gx NAME 'value'` for fish) or as
+// a .env file. names the format can't use and values it can't hold are reported as a *FlattenError
+func Export(vars []EnvVar, format string) (string, error) {
+	sb := strings.Builder{}
+
+	for _, v := range vars {
+		var line string
+		var err error
+
+		switch format {
+		case EXPORT_FORMAT_BASH, EXPORT_FORMAT_ZSH:
+			line, err = exportShell(v, format, "export %s=%s\n", quoteSh)
+		case EXPORT_FORMAT_FISH:
+			line, err = exportShell(v, format, "set -gx %s %s\n", quoteFish)
+		case EXPORT_FORMAT_DOTENV:
+			line, err = exportDotenv(v)
+		default:
+			return "", &FlattenError{Message: fmt.Sprintf("unknown export format `%s`, expected %s, %s, %s or %s", format, EXPORT_FORMAT_BASH, EXPORT_FORMAT_ZSH, EXPORT_FORMAT_FISH, EXPORT_FORMAT_DOTENV)}
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(line)
+	}
+
+	return sb.String(), nil
+}
+
+func exportShell(v EnvVar, format string, line string, quote func(string) string) (string, error) {
+	if !shellName.MatchString(v.Name) {
+		return "", &FlattenError{Path: v.Path, Message: fmt.Sprintf("%s isn't a valid variable name in %s", v.Name, format)}
+	}
+
+	return fmt.Sprintf(line, v.Name, quote(v.Value)), nil
+}
+
+// quoteSh single quotes s, inside single quotes nothing is special except the closing quote itself
+func quoteSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteFish single quotes s, fish also treats backslashes as escapes inside single quotes
+func quoteFish(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+func exportDotenv(v EnvVar) (string, error) {
+	if !dotenvName.MatchString(v.Name) {
+		return "", &FlattenError{Path: v.Path, Message: fmt.Sprintf("%s isn't a valid variable name in a .env file", v.Name)}
+	}
+
+	if dotenvPlain.MatchString(v.Value) {
+		return fmt.Sprintf("%s=%s\n", v.Name, v.Value), nil
+	}
+
+	sb := strings.Builder{}
+
+	for _, c := range v.Value {
+		switch c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '$':
+			// dotenv loaders and shells expand variables and commands inside double quotes
+			sb.WriteString(`\$`)
+		case '`':
+			sb.WriteString("\\`")
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				return "", &FlattenError{Path: v.Path, Message: fmt.Sprintf("control character %U can't be written to a .env file", c)}
+			}
+
+			sb.WriteRune(c)
+		}
+	}
+
+	return fmt.Sprintf("%s=\"%s\"\n", v.Name, sb.String()), nil
+}
@@ -0,0 +1,127 @@
+package mconf
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var exportVars = []EnvVar{
+	{Name: "PLAIN", Value: "a/b:c", Path: "plain"},
+	{Name: "EMPTY", Value: "", Path: "empty"},
+	{Name: "QUOTES", Value: `it's "x" \ y`, Path: "quotes"},
+	{Name: "EXPANDED", Value: "$HOME `id` $(id) ${HOME}", Path: "expanded"},
+	{Name: "LINES", Value: "a\nb\tc", Path: "lines"},
+}
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			EXPORT_FORMAT_BASH,
+			"export PLAIN='a/b:c'\n" +
+				"export EMPTY=''\n" +
+				"export QUOTES='it'\\''s \"x\" \\ y'\n" +
+				"export EXPANDED='$HOME `id` $(id) ${HOME}'\n" +
+				"export LINES='a\nb\tc'\n",
+		},
+		{
+			EXPORT_FORMAT_FISH,
+			"set -gx PLAIN 'a/b:c'\n" +
+				"set -gx EMPTY ''\n" +
+				"set -gx QUOTES 'it\\'s \"x\" \\\\ y'\n" +
+				"set -gx EXPANDED '$HOME `id` $(id) ${HOME}'\n" +
+				"set -gx LINES 'a\nb\tc'\n",
+		},
+		{
+			EXPORT_FORMAT_DOTENV,
+			"PLAIN=a/b:c\n" +
+				"EMPTY=\n" +
+				"QUOTES=\"it's \\\"x\\\" \\\\ y\"\n" +
+				"EXPANDED=\"\\$HOME \\`id\\` \\$(id) \\${HOME}\"\n" +
+				"LINES=\"a\\nb\\tc\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := Export(exportVars, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != tt.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", out, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExportSourced checks that a POSIX shell reads back every value unchanged, from both the shell and the .env output
+func TestExportSourced(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to source the output with")
+	}
+
+	for _, format := range []string{EXPORT_FORMAT_BASH, EXPORT_FORMAT_DOTENV} {
+		t.Run(format, func(t *testing.T) {
+			out, err := Export(exportVars, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file := filepath.Join(t.TempDir(), "vars")
+			if err := os.WriteFile(file, []byte(out), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range exportVars {
+				// .env files only know the escapes of double quotes, a shell leaves \n and \t alone
+				if format == EXPORT_FORMAT_DOTENV && v.Name == "LINES" {
+					continue
+				}
+
+				script := `set -a; . "$1"; printf '%s' "$` + v.Name + `"`
+
+				got, err := exec.Command(sh, "-c", script, "sh", file).Output()
+				if err != nil {
+					t.Fatalf("sourcing failed: %v\n%s", err, out)
+				}
+
+				if string(got) != v.Value {
+					t.Errorf("%s read back as %q, expected %q", v.Name, got, v.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		vars   []EnvVar
+		format string
+	}{
+		{"shell name", []EnvVar{{Name: "a.b", Value: "x", Path: "a.b"}}, EXPORT_FORMAT_BASH},
+		{"fish name", []EnvVar{{Name: "1A", Value: "x", Path: "1a"}}, EXPORT_FORMAT_FISH},
+		{"dotenv name", []EnvVar{{Name: "A B", Value: "x", Path: "a b"}}, EXPORT_FORMAT_DOTENV},
+		{"dotenv control character", []EnvVar{{Name: "A", Value: "\x01", Path: "a"}}, EXPORT_FORMAT_DOTENV},
+		{"format", exportVars, "powershell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Export(tt.vars, tt.format)
+
+			var flattenErr *FlattenError
+			if !errors.As(err, &flattenErr) {
+				t.Errorf("expected a *FlattenError, got %v", err)
+			}
+		})
+	}
+}
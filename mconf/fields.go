@@ -0,0 +1,118 @@
+package mconf
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map
+
+// parseTag splits a `mconf:"name,omitempty"` tag into its parts
+func parseTag(tag string) (string, bool) {
+	name, opts, _ := strings.Cut(tag, ",")
+	omitEmpty := false
+
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty
+}
+
+// structFields lists the fields of t that take part in decoding and encoding, with embedded structs flattened
+func structFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	fields := collectFields(t, nil)
+	fieldCache.Store(t, fields)
+
+	return fields
+}
+
+func collectFields(t reflect.Type, parentIndex []int) []field {
+	fields := []field{}
+	embedded := []field{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("mconf")
+
+		if tag == "-" {
+			continue
+		}
+
+		name, omitEmpty := parseTag(tag)
+
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		if sf.Anonymous && (!hasTag || name == "") {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, collectFields(ft, index)...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, field{name: name, index: index, omitEmpty: omitEmpty})
+	}
+
+	// fields declared directly on the struct win over ones promoted from embedded structs
+	for _, ef := range embedded {
+		shadowed := false
+
+		for _, f := range fields {
+			if f.name == ef.name {
+				shadowed = true
+				break
+			}
+		}
+
+		if !shadowed {
+			fields = append(fields, ef)
+		}
+	}
+
+	return fields
+}
+
+// fieldByName finds the field for a key, falling back to a case-insensitive match
+func fieldByName(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
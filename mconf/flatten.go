@@ -0,0 +1,189 @@
+package mconf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+const (
+	FLATTEN_LISTS_INDEXED = "indexed"
+	FLATTEN_LISTS_JSON    = "json"
+)
+
+const (
+	FLATTEN_CASE_UPPER    = "upper"
+	FLATTEN_CASE_LOWER    = "lower"
+	FLATTEN_CASE_PRESERVE = "preserve"
+)
+
+// EnvVar is a single environment variable produced by Flatten
+type EnvVar struct {
+	Name  string
+	Value string
+	// Path is where the value came from, printed like `servers[1].port`
+	Path string
+}
+
+// FlattenOptions controls how Flatten names variables and represents lists
+type FlattenOptions struct {
+	// Prefix is put in front of every name as is
+	Prefix string
+	// Separator joins the keys of nested values, `_` by default
+	Separator string
+	// Case is FLATTEN_CASE_UPPER (the default), FLATTEN_CASE_LOWER or FLATTEN_CASE_PRESERVE, the prefix is never changed
+	Case string
+	// Lists is FLATTEN_LISTS_INDEXED (the default) for a variable per element (`TAGS_0`, `TAGS_1`...)
+	// or FLATTEN_LISTS_JSON for a single variable holding the list as JSON
+	Lists string
+}
+
+// FlattenError reports a value that can't be turned into an environment variable
+type FlattenError struct {
+	Path    string
+	Message string
+}
+
+func (e *FlattenError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("mconf: cannot flatten: %s", e.Message)
+	}
+
+	return fmt.Sprintf("mconf: cannot flatten %s: %s", e.Path, e.Message)
+}
+
+type flattener struct {
+	opts FlattenOptions
+	vars []EnvVar
+	// names maps every name handed out to the path it came from, so that two keys that end up with the same name are caught
+	names map[string]string
+}
+
+// Flatten turns a value into environment variables. nested keys are joined with `_` and upper cased by default, anything
+// in a key that isn't a letter, a digit or `_` becomes `_` (`db.host` becomes `DB_HOST`). null becomes an empty string and
+// objects and lists are expanded, unless lists are encoded as JSON. a value that isn't an object or a list is named by the prefix alone
+func Flatten(v parser.ParserValue, opts FlattenOptions) ([]EnvVar, error) {
+	if opts.Lists == "" {
+		opts.Lists = FLATTEN_LISTS_INDEXED
+	}
+
+	if opts.Separator == "" {
+		opts.Separator = "_"
+	}
+
+	if opts.Case == "" {
+		opts.Case = FLATTEN_CASE_UPPER
+	}
+
+	if opts.Lists != FLATTEN_LISTS_INDEXED && opts.Lists != FLATTEN_LISTS_JSON {
+		return nil, &FlattenError{Message: fmt.Sprintf("unknown list mode `%s`, expected %s or %s", opts.Lists, FLATTEN_LISTS_INDEXED, FLATTEN_LISTS_JSON)}
+	}
+
+	if opts.Case != FLATTEN_CASE_UPPER && opts.Case != FLATTEN_CASE_LOWER && opts.Case != FLATTEN_CASE_PRESERVE {
+		return nil, &FlattenError{Message: fmt.Sprintf("unknown case `%s`, expected %s, %s or %s", opts.Case, FLATTEN_CASE_UPPER, FLATTEN_CASE_LOWER, FLATTEN_CASE_PRESERVE)}
+	}
+
+	f := flattener{opts: opts, names: map[string]string{}}
+
+	err := f.flatten(v, []string{}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.vars, nil
+}
+
+// EnvName turns the keys leading to a value into a variable name the way Flatten does, opts must have every field set
+func EnvName(keys []string, opts FlattenOptions) string {
+	parts := []string{}
+
+	for _, k := range keys {
+		switch opts.Case {
+		case FLATTEN_CASE_UPPER:
+			k = strings.ToUpper(k)
+		case FLATTEN_CASE_LOWER:
+			k = strings.ToLower(k)
+		}
+
+		sb := strings.Builder{}
+
+		for _, c := range k {
+			if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' {
+				sb.WriteRune(c)
+			} else {
+				sb.WriteByte('_')
+			}
+		}
+
+		parts = append(parts, sb.String())
+	}
+
+	return opts.Prefix + strings.Join(parts, opts.Separator)
+}
+
+func (f *flattener) flatten(v parser.ParserValue, keys []string, path string) error {
+	switch v.GetType() {
+	case parser.PARSER_VALUE_TYPE_OBJECT:
+		obj, _ := v.GetObject()
+
+		for _, k := range obj.Keys() {
+			item, _ := obj.Get(k)
+
+			err := f.flatten(item, with(keys, k), parser.AppendPathKey(path, k))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case parser.PARSER_VALUE_TYPE_LIST:
+		if f.opts.Lists == FLATTEN_LISTS_JSON {
+			return f.add(keys, path, v.ToJSONString())
+		}
+
+		list, _ := v.GetList()
+
+		for i, item := range list {
+			err := f.flatten(item, with(keys, fmt.Sprint(i)), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case parser.PARSER_VALUE_TYPE_STRING:
+		s, _ := v.GetString()
+		return f.add(keys, path, s)
+	case parser.PARSER_VALUE_TYPE_NULL:
+		return f.add(keys, path, "")
+	default:
+		// ints, floats and bools
+		return f.add(keys, path, v.ValueToString())
+	}
+}
+
+func (f *flattener) add(keys []string, path string, value string) error {
+	name := EnvName(keys, f.opts)
+
+	if len(keys) == 0 {
+		name = strings.TrimSuffix(f.opts.Prefix, f.opts.Separator)
+	}
+
+	if name == "" {
+		return &FlattenError{Path: path, Message: "a single value needs a prefix to be named by"}
+	}
+
+	if strings.ContainsRune(value, 0) {
+		return &FlattenError{Path: path, Message: "environment variables can't contain NUL characters"}
+	}
+
+	if other, ok := f.names[name]; ok {
+		return &FlattenError{Path: path, Message: fmt.Sprintf("both %s and %s would be named %s", other, path, name)}
+	}
+
+	f.names[name] = path
+	f.vars = append(f.vars, EnvVar{Name: name, Value: value, Path: path})
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package mconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestFlatten(t *testing.T) {
+	value := parseObject(t, `
+		db = { host = "a", port = 5432, "max-conns" = 1.5 }
+		tags = ["x", "y"]
+		debug = false
+		extra = null
+		servers = [{ name = "é" }]
+	`)
+
+	tests := []struct {
+		name     string
+		opts     FlattenOptions
+		expected map[string]string
+	}{
+		{
+			"defaults",
+			FlattenOptions{},
+			map[string]string{
+				"DB_HOST": "a", "DB_PORT": "5432", "DB_MAX_CONNS": "1.5", "TAGS_0": "x", "TAGS_1": "y",
+				"DEBUG": "false", "EXTRA": "", "SERVERS_0_NAME": "é",
+			},
+		},
+		{
+			"prefix, separator and case",
+			FlattenOptions{Prefix: "App.", Separator: ".", Case: FLATTEN_CASE_LOWER},
+			map[string]string{
+				"App.db.host": "a", "App.db.port": "5432", "App.db.max_conns": "1.5", "App.tags.0": "x", "App.tags.1": "y",
+				"App.debug": "false", "App.extra": "", "App.servers.0.name": "é",
+			},
+		},
+		{
+			"json lists",
+			FlattenOptions{Case: FLATTEN_CASE_PRESERVE, Lists: FLATTEN_LISTS_JSON},
+			map[string]string{
+				"db_host": "a", "db_port": "5432", "db_max_conns": "1.5", "tags": `["x","y"]`,
+				"debug": "false", "extra": "", "servers": `[{"name":"é"}]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := Flatten(value, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+
+			for _, v := range vars {
+				got[v.Name] = v.Value
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenSingleValue(t *testing.T) {
+	vars, err := Flatten(&parser.ParserValueString{Value: "x"}, FlattenOptions{Prefix: "APP_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vars) != 1 || vars[0].Name != "APP" || vars[0].Value != "x" {
+		t.Errorf("unexpected variables %v", vars)
+	}
+}
+
+func TestFlattenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value parser.ParserValue
+		path  string
+	}{
+		{"same name", parseObject(t, `a = { b = 1 }`+"\n"+`"a.b" = 2`), `"a.b"`},
+		{"nul", parseObject(t, `a = "x\u0000y"`), "a"},
+		{"no prefix", &parser.ParserValueString{Value: "x"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Flatten(tt.value, FlattenOptions{})
+
+			var flattenErr *FlattenError
+			if !errors.As(err, &flattenErr) {
+				t.Fatalf("expected a *FlattenError, got %v", err)
+			}
+
+			if flattenErr.Path != tt.path {
+				t.Errorf("error at %q, expected %q: %v", flattenErr.Path, tt.path, err)
+			}
+		})
+	}
+}
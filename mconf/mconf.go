@@ -0,0 +1,292 @@
+package mconf
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/parser"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// Result holds everything a parse produced: the values of the root file and the constants that ended up in scope
+type Result struct {
+	Values    *parser.OrderedMap
+	Constants *parser.OrderedMap
+	// Unset are the paths the root file removed with `@unset`, Merge removes them from the results merged before it
+	Unset [][]string
+}
+
+// Object returns the values of the root file wrapped in an object, which is handy for printing or indexing
+func (r *Result) Object() *parser.ParserValueObject {
+	return &parser.ParserValueObject{Value: r.Values}
+}
+
+type options struct {
+	dir          string
+	filename     string
+	fsys         fs.FS
+	env          map[string]string
+	envAllowlist []string
+	envPrefixes  []string
+	readFiles    *[]string
+
+	// dirSet and filenameSet tell Load that dir and filename were passed explicitly, it doesn't derive them from the path then
+	dirSet      bool
+	filenameSet bool
+}
+
+// Option configures how a source is parsed
+type Option func(*options)
+
+// WithDir sets the directory that imports are resolved against (defaults to the current working directory)
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+		o.dirSet = true
+	}
+}
+
+// WithFilename sets the name of the parsed file, used in error messages and to detect self-imports
+func WithFilename(filename string) Option {
+	return func(o *options) {
+		o.filename = filename
+		o.filenameSet = true
+	}
+}
+
+// WithFS reads the root file (for Load) and every import from fsys instead of the operating system, paths are then paths inside of fsys
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithEnv makes constants fall back to env instead of the process environment
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+// WithoutEnv disables the environment fallback entirely, only constants defined in the parsed files are visible
+func WithoutEnv() Option {
+	return WithEnv(map[string]string{})
+}
+
+// WithEnvAllowlist only exposes the listed environment variables
+func WithEnvAllowlist(names ...string) Option {
+	return func(o *options) {
+		o.envAllowlist = append(o.envAllowlist, names...)
+	}
+}
+
+// WithEnvPrefix only exposes environment variables starting with prefix
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefixes = append(o.envPrefixes, prefix)
+	}
+}
+
+// WithReadFiles stores the path of the root file (unless the source didn't come from a file) and of every file an import
+// read or tried to read in *files once parsing is done, even when it failed. paths are absolute, or paths inside of the
+// fs.FS given to WithFS. this is what a tool that re-parses on changes needs to know which files to watch
+func WithReadFiles(files *[]string) Option {
+	return func(o *options) {
+		o.readFiles = files
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		dir:      ".",
+		filename: "",
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// readError keeps the underlying error around for errors.Is(err, fs.ErrNotExist) and friends
+type readError struct {
+	filename string
+	err      error
+}
+
+func (e *readError) Error() string {
+	return fmt.Sprintf("%s - Error reading file, %s", e.filename, parser.ReadErrorReason(e.err))
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// Load reads and parses the file at filename, resolving imports relative to the file's directory
+func Load(filename string, opts ...Option) (*Result, error) {
+	o := newOptions(opts)
+
+	f, err := o.readRoot(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(string(f), o)
+}
+
+// readRoot reads the file Load parses, the directory and name of the file are used unless they were passed explicitly
+func (o *options) readRoot(filename string) ([]byte, error) {
+	var f []byte
+	var err error
+	var dir, base string
+
+	if o.fsys != nil {
+		f, err = fs.ReadFile(o.fsys, filename)
+		dir, base = path.Dir(filename), path.Base(filename)
+	} else {
+		f, err = os.ReadFile(filename)
+		dir, base = filepath.Dir(filename), filepath.Base(filename)
+	}
+
+	if err != nil {
+		return nil, &readError{filename: filename, err: err}
+	}
+
+	if !o.dirSet {
+		o.dir = dir
+	}
+
+	if !o.filenameSet {
+		o.filename = base
+	}
+
+	return f, nil
+}
+
+// Parse parses src as the contents of an mconf file
+func Parse(src []byte, opts ...Option) (*Result, error) {
+	return parse(string(src), newOptions(opts))
+}
+
+// ParseReader reads r until EOF and parses what it read
+func ParseReader(r io.Reader, opts ...Option) (*Result, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(string(b), newOptions(opts))
+}
+
+// environment takes the snapshot of the environment used for a whole parse, allowlists and prefixes are combined, passing either is enough.
+// the parser only calls it once a constant falls back to the environment
+func (o *options) environment() map[string]string {
+	source := o.env
+
+	if source == nil {
+		source = make(map[string]string)
+
+		for _, e := range os.Environ() {
+			pair := strings.SplitN(e, "=", 2)
+			source[pair[0]] = pair[1]
+		}
+	}
+
+	if len(o.envAllowlist) == 0 && len(o.envPrefixes) == 0 {
+		return source
+	}
+
+	env := make(map[string]string)
+
+	for k, v := range source {
+		allowed := false
+
+		for _, name := range o.envAllowlist {
+			if k == name {
+				allowed = true
+			}
+		}
+
+		for _, prefix := range o.envPrefixes {
+			if strings.HasPrefix(k, prefix) {
+				allowed = true
+			}
+		}
+
+		if allowed {
+			env[k] = v
+		}
+	}
+
+	return env
+}
+
+// localPath turns a path the parser uses into one of the operating system, paths inside of an fs.FS are left alone
+func localPath(p string, fsys fs.FS) string {
+	if fsys != nil {
+		return p
+	}
+
+	return filepath.FromSlash(p)
+}
+
+func parse(s string, o options) (*Result, error) {
+	var rootDir string
+
+	if o.fsys != nil {
+		rootDir = path.Clean(filepath.ToSlash(o.dir))
+	} else {
+		absDir, err := filepath.Abs(o.dir)
+		if err != nil {
+			return nil, err
+		}
+
+		rootDir = filepath.ToSlash(absDir)
+	}
+
+	// tokenise errors don't stop the parse so that every problem in the file gets reported at once
+	var errs diagnostics.List
+
+	t := tokeniser.NewTokeniser(s, o.filename, o.dir)
+	tokens, err := t.Tokenise()
+	errs.Add(err)
+
+	p := parser.NewParser(tokens, rootDir, o.filename, o.dir)
+
+	if o.fsys != nil {
+		p.SetFS(o.fsys)
+	}
+
+	p.SetEnvSource(o.environment)
+	values, err := p.Parse()
+	errs.Add(err)
+
+	if o.readFiles != nil {
+		*o.readFiles = []string{}
+
+		if o.filename != "" {
+			*o.readFiles = append(*o.readFiles, localPath(path.Join(rootDir, o.filename), o.fsys))
+		}
+
+		for _, f := range p.GetImportedFiles() {
+			*o.readFiles = append(*o.readFiles, localPath(f, o.fsys))
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Values:    values,
+		Constants: p.GetConstants(),
+		Unset:     p.GetUnset(),
+	}, nil
+}
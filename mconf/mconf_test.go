@@ -0,0 +1,225 @@
+package mconf
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEnvFilter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.mconf")
+
+	src := `
+		prefixed = $APP_PORT?"unset"
+		allowed = $KEEP?"unset"
+		other = $OTHER?"unset"
+	`
+
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := []Option{
+		WithEnv(map[string]string{"APP_PORT": "80", "KEEP": "yes", "OTHER": "no"}),
+		WithEnvPrefix("APP_"),
+		WithEnvAllowlist("KEEP"),
+	}
+
+	// options are applied once, so the filter isn't doubled up
+	o := newOptions(opts)
+	if _, err := o.readRoot(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(o.envPrefixes, []string{"APP_"}) || !reflect.DeepEqual(o.envAllowlist, []string{"KEEP"}) {
+		t.Errorf("unexpected filter: prefixes %v, allowlist %v", o.envPrefixes, o.envAllowlist)
+	}
+
+	if env := o.environment(); !reflect.DeepEqual(env, map[string]string{"APP_PORT": "80", "KEEP": "yes"}) {
+		t.Errorf("unexpected environment %v", env)
+	}
+
+	result, err := Load(file, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"prefixed": `"80"`, "allowed": `"yes"`, "other": `"unset"`}
+
+	for key, want := range expected {
+		v, _ := result.Values.Get(key)
+
+		if got := v.ValueToString(); got != want {
+			t.Errorf("%s = %s, expected %s", key, got, want)
+		}
+	}
+}
+
+func TestLoadExplicitDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.mconf")
+
+	if err := os.WriteFile(file, []byte("a = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := newOptions([]Option{WithDir("elsewhere")})
+	if _, err := o.readRoot(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.dir != "elsewhere" || o.filename != "config.mconf" {
+		t.Errorf("dir %q and filename %q, expected elsewhere and config.mconf", o.dir, o.filename)
+	}
+}
+
+func TestParse(t *testing.T) {
+	result, err := Parse([]byte(`
+		$name = "app"
+		title = "${name} server"
+		port = 8080
+	`), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.Object().ValueToString(); got != `{ title = "app server", port = 8080 }` {
+		t.Errorf("unexpected values %s", got)
+	}
+
+	if name, ok := result.Constants.Get("name"); !ok || name.ValueToString() != `"app"` {
+		t.Errorf("expected the constant $name to be in the result")
+	}
+
+	if _, err := ParseReader(strings.NewReader("a = ")); err == nil {
+		t.Error("expected an error for a missing value")
+	}
+}
+
+func TestLoadImportsRelativeToFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"main.mconf":   "@import \"shared.mconf\"\nport = $port",
+		"shared.mconf": "$port = 80",
+	}
+
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Load(filepath.Join(dir, "main.mconf"), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if port, _ := result.Values.Get("port"); port == nil || port.ValueToString() != "80" {
+		t.Errorf("expected port to be imported from shared.mconf")
+	}
+
+	_, err = Load(filepath.Join(dir, "missing.mconf"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"configs/app.mconf":         {Data: []byte("@import \"shared/base.mconf\"\n@import \"../top.mconf\"\nport = $port\nname = $name")},
+		"configs/shared/base.mconf": {Data: []byte("$port = 80")},
+		"top.mconf":                 {Data: []byte("$name = \"top\"")},
+	}
+
+	result, err := Load("configs/app.mconf", WithFS(fsys), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.Object().ValueToString(); got != `{ port = 80, name = "top" }` {
+		t.Errorf("unexpected values %s", got)
+	}
+
+	_, err = Parse([]byte(`@import "missing.mconf"`), WithFS(fsys), WithoutEnv())
+
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Errorf("expected an *ImportError, got %v", err)
+	}
+}
+
+func TestLoadReadFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// imports are resolved against the directory of the root file, nested ones too
+	files := map[string]string{
+		"main.mconf":       "@import \"a.mconf\"\n@import \"sub/b.mconf\"\n@import \"a.mconf\"",
+		"a.mconf":          "a = 1",
+		"sub/b.mconf":      "@import \"c.mconf\"\nb = 2",
+		"c.mconf":          "c = 3",
+		"unused.mconf":     "d = 4",
+		"sub/broken.mconf": "@import \"missing.mconf\"",
+	}
+
+	for name, src := range files {
+		file := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var readFiles []string
+
+	if _, err := Load(filepath.Join(dir, "main.mconf"), WithoutEnv(), WithReadFiles(&readFiles)); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(readFiles)
+
+	expected := []string{}
+	for _, name := range []string{"a.mconf", "c.mconf", "main.mconf", "sub/b.mconf"} {
+		expected = append(expected, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+
+	sort.Strings(expected)
+
+	if !reflect.DeepEqual(readFiles, expected) {
+		t.Errorf("read %v, expected %v", readFiles, expected)
+	}
+
+	// a file that failed to import is still there, so that creating it can be noticed
+	if _, err := Load(filepath.Join(dir, "sub", "broken.mconf"), WithoutEnv(), WithReadFiles(&readFiles)); err == nil {
+		t.Fatal("expected an error for a missing import")
+	}
+
+	expected = []string{filepath.Join(dir, "sub", "broken.mconf"), filepath.Join(dir, "sub", "missing.mconf")}
+
+	if !reflect.DeepEqual(readFiles, expected) {
+		t.Errorf("read %v, expected %v", readFiles, expected)
+	}
+
+	// sources that don't come from a file only list their imports
+	if _, err := Parse([]byte(`@import "a.mconf"`), WithDir(dir), WithoutEnv(), WithReadFiles(&readFiles)); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{filepath.Join(dir, "a.mconf")}; !reflect.DeepEqual(readFiles, expected) {
+		t.Errorf("read %v, expected %v", readFiles, expected)
+	}
+}
@@ -0,0 +1,50 @@
+package mconf
+
+import "github.com/marzeq/mconf/parser"
+
+// Merge deep merges results in order, each one overriding the ones before it (see parser.Merge for how values are combined).
+// paths a result removed with `@unset` are removed from everything merged before it, so an override file can drop keys
+// or, by unsetting a key and then defining it again, replace an object instead of merging into it
+func Merge(results []*Result, opts parser.MergeOptions) (*Result, error) {
+	if err := parser.ValidateMergeOptions(opts); err != nil {
+		return nil, err
+	}
+
+	merged := &Result{
+		Values:    parser.NewOrderedMap(),
+		Constants: parser.NewOrderedMap(),
+	}
+
+	for _, r := range results {
+		values := merged.Values.Copy()
+
+		for _, path := range r.Unset {
+			parser.DeletePath(values, path)
+		}
+
+		merged.Values = parser.MergeObjects(values, r.Values, opts)
+
+		for _, k := range r.Constants.Keys() {
+			v, _ := r.Constants.Get(k)
+			merged.Constants.Set(k, v)
+		}
+	}
+
+	return merged, nil
+}
+
+// MergeFiles loads every file with opts and merges them with Merge
+func MergeFiles(filenames []string, mergeOpts parser.MergeOptions, opts ...Option) (*Result, error) {
+	results := []*Result{}
+
+	for _, filename := range filenames {
+		result, err := Load(filename, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return Merge(results, mergeOpts)
+}
@@ -0,0 +1,47 @@
+package mconf
+
+import (
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestMerge(t *testing.T) {
+	sources := []string{
+		"$env = \"base\"\ndb = { host = \"a\", port = 1, opts = { ssl = true } }\ntags = [1]\nlegacy = 1",
+		"$env = \"prod\"\n@unset legacy\n@unset db.opts\ndb = { host = \"b\", opts = { timeout = 5 } }\ntags = [2]",
+	}
+
+	results := []*Result{}
+
+	for _, src := range sources {
+		result, err := Parse([]byte(src), WithoutEnv())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results = append(results, result)
+	}
+
+	merged, err := Merge(results, parser.MergeOptions{Lists: parser.MERGE_LISTS_APPEND})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, expected := merged.Object().ValueToString(), `{ db = { host = "b", port = 1, opts = { timeout = 5 } }, tags = [1, 2] }`; got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+
+	if env, _ := merged.Constants.Get("env"); env == nil || env.ValueToString() != `"prod"` {
+		t.Errorf("expected the last $env to win")
+	}
+
+	// the results that were merged stay as they were
+	if _, ok := results[0].Values.Get("legacy"); !ok {
+		t.Errorf("@unset removed legacy from the first result")
+	}
+
+	if _, err := Merge(results, parser.MergeOptions{Lists: "prepend"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,108 @@
+package mconf
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+// NativeOptions controls how ToNative represents numbers
+type NativeOptions struct {
+	// KeepBigNumbers returns ints as *big.Int and floats as *big.Float instead of narrowing them to int64 and float64
+	KeepBigNumbers bool
+}
+
+// ToNative converts a value into plain Go values: map[string]any, []any, string, bool, nil and numbers
+func ToNative(v parser.ParserValue, opts NativeOptions) (any, error) {
+	return toNative(v, opts, "")
+}
+
+// FromNative builds a value out of Go data, structs are converted the same way Marshal converts them
+func FromNative(v any) (parser.ParserValue, error) {
+	return encodeValue(reflect.ValueOf(v), "")
+}
+
+func toNative(value parser.ParserValue, opts NativeOptions, path string) (any, error) {
+	switch value.GetType() {
+	case parser.PARSER_VALUE_TYPE_STRING:
+		return value.GetString()
+	case parser.PARSER_VALUE_TYPE_BOOL:
+		return value.GetBool()
+	case parser.PARSER_VALUE_TYPE_NULL:
+		return nil, nil
+	case parser.PARSER_VALUE_TYPE_INT:
+		i, err := value.GetInt()
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.KeepBigNumbers {
+			return new(big.Int).Set(i), nil
+		}
+
+		if !i.IsInt64() {
+			return nil, &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows int64", i.String())}
+		}
+
+		return i.Int64(), nil
+	case parser.PARSER_VALUE_TYPE_FLOAT:
+		f, err := value.GetFloat()
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.KeepBigNumbers {
+			return new(big.Float).Copy(f), nil
+		}
+
+		f64, _ := f.Float64()
+		if math.IsInf(f64, 0) {
+			return nil, &DecodeError{Path: path, Message: fmt.Sprintf("value %s overflows float64", f.String())}
+		}
+
+		return f64, nil
+	case parser.PARSER_VALUE_TYPE_LIST:
+		list, err := value.GetList()
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]any, len(list))
+
+		for i, item := range list {
+			native, err := toNative(item, opts, joinIndex(path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = native
+		}
+
+		return out, nil
+	case parser.PARSER_VALUE_TYPE_OBJECT:
+		obj, err := value.GetObject()
+		if err != nil {
+			return nil, err
+		}
+
+		out := make(map[string]any, obj.Len())
+
+		for _, k := range obj.Keys() {
+			v, _ := obj.Get(k)
+
+			native, err := toNative(v, opts, joinKey(path, k))
+			if err != nil {
+				return nil, err
+			}
+
+			out[k] = native
+		}
+
+		return out, nil
+	default:
+		return nil, &DecodeError{Path: path, Message: fmt.Sprintf("unknown value type %s", value.GetType())}
+	}
+}
@@ -0,0 +1,93 @@
+package mconf
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestToNative(t *testing.T) {
+	result, err := Parse([]byte(`
+		name = "app"
+		port = 8080
+		ratio = 0.5
+		debug = false
+		extra = null
+		servers = [{ host = "a" }, 1]
+	`), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	native, err := ToNative(result.Object(), NativeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"name":    "app",
+		"port":    int64(8080),
+		"ratio":   0.5,
+		"debug":   false,
+		"extra":   nil,
+		"servers": []any{map[string]any{"host": "a"}, int64(1)},
+	}
+
+	if !reflect.DeepEqual(native, expected) {
+		t.Errorf("got %#v, expected %#v", native, expected)
+	}
+}
+
+func TestToNativeBigNumbers(t *testing.T) {
+	result, err := Parse([]byte("big = 123456789012345678901234567890\nhuge = 1.0e400"), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decodeErr *DecodeError
+	if _, err := ToNative(result.Object(), NativeOptions{}); !errors.As(err, &decodeErr) || decodeErr.Path != "big" {
+		t.Errorf("expected big to overflow int64, got %v", err)
+	}
+
+	native, err := ToNative(result.Object(), NativeOptions{KeepBigNumbers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := native.(map[string]any)
+
+	if i, ok := values["big"].(*big.Int); !ok || i.String() != "123456789012345678901234567890" {
+		t.Errorf("expected big to stay a *big.Int, got %#v", values["big"])
+	}
+
+	if _, ok := values["huge"].(*big.Float); !ok {
+		t.Errorf("expected huge to stay a *big.Float, got %#v", values["huge"])
+	}
+}
+
+func TestFromNative(t *testing.T) {
+	value, err := FromNative(map[string]any{
+		"port": 8080,
+		"tags": []string{"a", "b"},
+		"tls":  map[string]any{"enabled": true, "cert": nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := Parse([]byte(`
+		port = 8080
+		tags = ["a", "b"]
+		tls = { cert = null, enabled = true }
+	`), WithoutEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parser.Equal(value, expected.Object()) {
+		t.Errorf("got %s, expected %s", value.ValueToString(), expected.Object().ValueToString())
+	}
+}
@@ -0,0 +1,370 @@
+package mconf
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/marzeq/mconf/parser"
+)
+
+// FromTOML converts a TOML document into an object, tables become objects and arrays of tables lists of objects.
+// keys keep the order they were defined in, dates and times become strings and infinite or NaN floats are reported as a *ConvertError
+func FromTOML(data []byte) (parser.ParserValue, error) {
+	var document map[string]any
+
+	md, err := toml.Decode(string(data), &document)
+	if err != nil {
+		return nil, err
+	}
+
+	// the decoded maps are unordered, the metadata remembers where every key was defined
+	order := map[string]int{}
+
+	for i, key := range md.Keys() {
+		joined := strings.Join(key, "\x00")
+
+		if _, ok := order[joined]; !ok {
+			order[joined] = i
+		}
+	}
+
+	return fromTOMLValue(document, order, []string{}, "")
+}
+
+func fromTOMLValue(value any, order map[string]int, keys []string, path string) (parser.ParserValue, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		names := []string{}
+
+		for k := range v {
+			names = append(names, k)
+		}
+
+		position := func(k string) int {
+			if i, ok := order[strings.Join(with(keys, k), "\x00")]; ok {
+				return i
+			}
+
+			return math.MaxInt
+		}
+
+		sort.Strings(names)
+		sort.SliceStable(names, func(i, j int) bool {
+			return position(names[i]) < position(names[j])
+		})
+
+		object := parser.NewOrderedMap()
+
+		for _, k := range names {
+			item, err := fromTOMLValue(v[k], order, with(keys, k), parser.AppendPathKey(path, k))
+			if err != nil {
+				return nil, err
+			}
+
+			object.Set(k, item)
+		}
+
+		return &parser.ParserValueObject{Value: object}, nil
+	case []map[string]any:
+		list := []parser.ParserValue{}
+
+		for i, table := range v {
+			item, err := fromTOMLValue(table, order, keys, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, item)
+		}
+
+		return &parser.ParserValueList{Value: list}, nil
+	case []any:
+		list := []parser.ParserValue{}
+
+		for i, element := range v {
+			item, err := fromTOMLValue(element, order, keys, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, item)
+		}
+
+		return &parser.ParserValueList{Value: list}, nil
+	case string:
+		return &parser.ParserValueString{Value: v}, nil
+	case bool:
+		return &parser.ParserValueBool{Value: v}, nil
+	case int64:
+		return &parser.ParserValueInt{Value: big.NewInt(v)}, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, &ConvertError{Format: "TOML", Path: path, Message: "infinite and NaN floats have no mconf equivalent"}
+		}
+
+		return &parser.ParserValueFloat{Value: big.NewFloat(v)}, nil
+	case time.Time:
+		return &parser.ParserValueString{Value: formatTOMLTime(v)}, nil
+	default:
+		return nil, &ConvertError{Format: "TOML", Path: path, Message: fmt.Sprintf("unsupported value of type %T", value)}
+	}
+}
+
+// formatTOMLTime prints a date or time the way it was written, the toml package marks local dates and times with special zones
+func formatTOMLTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
+// ToTOML converts an object into a TOML document. objects become tables and lists of objects arrays of tables,
+// objects nested in other lists are written as inline tables. TOML has no null and no ints beyond 64 bits, those
+// (and top level values that aren't objects) are reported as a *ConvertError
+func ToTOML(v parser.ParserValue) ([]byte, error) {
+	obj, err := v.GetObject()
+	if err != nil {
+		return nil, &ConvertError{Format: "TOML", Message: fmt.Sprintf("only objects can be written as TOML, got %s", strings.ToLower(v.GetType()))}
+	}
+
+	e := tomlEncoder{}
+
+	err = e.table(obj, []string{}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(e.sb.String()), nil
+}
+
+type tomlEncoder struct {
+	sb strings.Builder
+}
+
+// isTOMLTable reports whether v is written as a section of its own, either a table or an array of tables
+func isTOMLTable(v parser.ParserValue) bool {
+	if v.GetType() == parser.PARSER_VALUE_TYPE_OBJECT {
+		return true
+	}
+
+	list, err := v.GetList()
+	if err != nil || len(list) == 0 {
+		return false
+	}
+
+	for _, item := range list {
+		if item.GetType() != parser.PARSER_VALUE_TYPE_OBJECT {
+			return false
+		}
+	}
+
+	return true
+}
+
+// table writes the plain keys of obj and then every table nested in it, TOML doesn't allow plain keys after a table header
+func (e *tomlEncoder) table(obj *parser.OrderedMap, keys []string, path string) error {
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+
+		if isTOMLTable(v) {
+			continue
+		}
+
+		s, err := e.inline(v, parser.AppendPathKey(path, k))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&e.sb, "%s = %s\n", tomlKey(k), s)
+	}
+
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+
+		if !isTOMLTable(v) {
+			continue
+		}
+
+		tableKeys := with(keys, k)
+		tablePath := parser.AppendPathKey(path, k)
+
+		if child, err := v.GetObject(); err == nil {
+			// a table with nothing but other tables in it doesn't need a header of its own
+			if !onlyTables(child) {
+				e.header("[%s]", tableKeys)
+			}
+
+			err := e.table(child, tableKeys, tablePath)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		list, _ := v.GetList()
+
+		for i, item := range list {
+			child, _ := item.GetObject()
+			e.header("[[%s]]", tableKeys)
+
+			err := e.table(child, tableKeys, fmt.Sprintf("%s[%d]", tablePath, i))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func onlyTables(obj *parser.OrderedMap) bool {
+	if obj.Len() == 0 {
+		return false
+	}
+
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+
+		if !isTOMLTable(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *tomlEncoder) header(format string, keys []string) {
+	if e.sb.Len() > 0 {
+		e.sb.WriteString("\n")
+	}
+
+	parts := []string{}
+
+	for _, k := range keys {
+		parts = append(parts, tomlKey(k))
+	}
+
+	fmt.Fprintf(&e.sb, format+"\n", strings.Join(parts, "."))
+}
+
+func (e *tomlEncoder) inline(v parser.ParserValue, path string) (string, error) {
+	switch v.GetType() {
+	case parser.PARSER_VALUE_TYPE_OBJECT:
+		obj, _ := v.GetObject()
+
+		if obj.Len() == 0 {
+			return "{}", nil
+		}
+
+		parts := []string{}
+
+		for _, k := range obj.Keys() {
+			item, _ := obj.Get(k)
+
+			s, err := e.inline(item, parser.AppendPathKey(path, k))
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, fmt.Sprintf("%s = %s", tomlKey(k), s))
+		}
+
+		return fmt.Sprintf("{ %s }", strings.Join(parts, ", ")), nil
+	case parser.PARSER_VALUE_TYPE_LIST:
+		list, _ := v.GetList()
+		parts := []string{}
+
+		for i, item := range list {
+			s, err := e.inline(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, s)
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(parts, ", ")), nil
+	case parser.PARSER_VALUE_TYPE_STRING:
+		s, _ := v.GetString()
+		return tomlString(s), nil
+	case parser.PARSER_VALUE_TYPE_INT:
+		i, _ := v.GetInt()
+
+		if !i.IsInt64() {
+			return "", &ConvertError{Format: "TOML", Path: path, Message: fmt.Sprintf("%s doesn't fit in a 64-bit int, the largest TOML allows", i.String())}
+		}
+
+		return i.String(), nil
+	case parser.PARSER_VALUE_TYPE_FLOAT:
+		f, _ := v.GetFloat()
+
+		// TOML floats are float64s, a value that overflows or underflows to zero in one would be read back wrong
+		if f64, _ := f.Float64(); math.IsInf(f64, 0) || (f64 == 0 && f.Sign() != 0) {
+			return "", &ConvertError{Format: "TOML", Path: path, Message: fmt.Sprintf("%s doesn't fit in a 64-bit float, which is what TOML uses", v.ValueToString())}
+		}
+
+		return v.ValueToString(), nil
+	case parser.PARSER_VALUE_TYPE_NULL:
+		return "", &ConvertError{Format: "TOML", Path: path, Message: "TOML has no null, remove the key or give it a value"}
+	default:
+		// bools are spelled the same in mconf and TOML
+		return v.ValueToString(), nil
+	}
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(k string) string {
+	if tomlBareKey.MatchString(k) {
+		return k
+	}
+
+	return tomlString(k)
+}
+
+func tomlString(s string) string {
+	sb := strings.Builder{}
+	sb.WriteByte('"')
+
+	for _, c := range s {
+		switch c {
+		case '\\':
+			sb.WriteString("\\\\")
+		case '"':
+			sb.WriteString("\\\"")
+		case '\b':
+			sb.WriteString("\\b")
+		case '\t':
+			sb.WriteString("\\t")
+		case '\n':
+			sb.WriteString("\\n")
+		case '\f':
+			sb.WriteString("\\f")
+		case '\r':
+			sb.WriteString("\\r")
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&sb, "\\u%04x", c)
+			} else {
+				sb.WriteRune(c)
+			}
+		}
+	}
+
+	sb.WriteByte('"')
+
+	return sb.String()
+}
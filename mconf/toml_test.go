@@ -0,0 +1,143 @@
+package mconf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestFromTOML(t *testing.T) {
+	src := `
+z = 1
+a = "x"
+date = 2024-01-02
+time = 07:32:00
+float = 1.5
+
+[server]
+port = 8080
+tls = { cert = "c", enabled = true }
+
+[[users]]
+name = "b"
+
+[[users]]
+name = "a"
+`
+
+	value, err := FromTOML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{ z = 1, a = "x", date = "2024-01-02", time = "07:32:00", float = 1.5, server = { port = 8080, tls = { cert = "c", enabled = true } }, users = [{ name = "b" }, { name = "a" }] }`
+
+	if got := value.ValueToString(); got != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	var convertErr *ConvertError
+	if _, err := FromTOML([]byte("a = inf")); !errors.As(err, &convertErr) {
+		t.Errorf("expected a *ConvertError for an infinite float, got %v", err)
+	}
+}
+
+func TestToTOML(t *testing.T) {
+	value := parseObject(t, `
+		name = "app"
+		"my key" = [1, 2]
+		db = { host = "a", opts = { ssl = true } }
+		nested = { only = { x = 1 } }
+		users = [{ name = "a" }, { name = "b", roles = [{ id = 1 }] }]
+		mixed = [1, { a = 1 }]
+	`)
+
+	out, err := ToTOML(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `name = "app"
+"my key" = [1, 2]
+mixed = [1, { a = 1 }]
+
+[db]
+host = "a"
+
+[db.opts]
+ssl = true
+
+[nested.only]
+x = 1
+
+[[users]]
+name = "a"
+
+[[users]]
+name = "b"
+
+[[users.roles]]
+id = 1
+`
+
+	if string(out) != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	value := parseObject(t, `
+		strings = ["", "quote \" and \\", "tab\tnew\nline", "é", "\u007f"]
+		numbers = [0, -9223372036854775808, 9223372036854775807, 0.1, 1.0e+300, -2.5]
+		keys = { "a.b" = 1, "" = 2, "with space" = 3, "bare-key_1" = 4 }
+		tables = [{ a = { b = [{ c = 1 }] } }]
+		empty = { }
+	`)
+
+	out, err := ToTOML(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := FromTOML(out)
+	if err != nil {
+		t.Fatalf("unexpected error reading back:\n%s\n%v", out, err)
+	}
+
+	if !parser.Equal(value, back) {
+		t.Errorf("values changed, got %s from:\n%s", back.ValueToString(), out)
+	}
+}
+
+func TestToTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		path string
+	}{
+		{"null", "a = { b = null }", "a.b"},
+		{"big int", "a = [123456789012345678901234567890]", "a[0]"},
+		{"float overflow", "a = 1.0e+600", "a"},
+		{"float underflow", "a = 1.0e-400", "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToTOML(parseObject(t, tt.src))
+
+			var convertErr *ConvertError
+			if !errors.As(err, &convertErr) {
+				t.Fatalf("expected a *ConvertError, got %v", err)
+			}
+
+			if convertErr.Path != tt.path {
+				t.Errorf("error at %q, expected %q: %v", convertErr.Path, tt.path, err)
+			}
+		})
+	}
+
+	if _, err := ToTOML(&parser.ParserValueList{}); err == nil {
+		t.Error("expected an error for a list at the top level")
+	}
+}
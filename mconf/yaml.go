@@ -0,0 +1,337 @@
+package mconf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/marzeq/mconf/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertError reports a value that has no equivalent in the format it is being converted to or from.
+// Line and Col point into the source when the reading side knows them, otherwise Path names the value
+type ConvertError struct {
+	Format  string
+	Line    int
+	Col     int
+	Path    string
+	Message string
+}
+
+func (e *ConvertError) Error() string {
+	switch {
+	case e.Line != 0:
+		return fmt.Sprintf("mconf: cannot convert %s at line %d, col %d: %s", e.Format, e.Line, e.Col, e.Message)
+	case e.Path != "":
+		return fmt.Sprintf("mconf: cannot convert %s at %s: %s", e.Format, e.Path, e.Message)
+	default:
+		return fmt.Sprintf("mconf: cannot convert %s: %s", e.Format, e.Message)
+	}
+}
+
+const (
+	yamlTagNull      = "!!null"
+	yamlTagBool      = "!!bool"
+	yamlTagInt       = "!!int"
+	yamlTagFloat     = "!!float"
+	yamlTagStr       = "!!str"
+	yamlTagTimestamp = "!!timestamp"
+	yamlTagBinary    = "!!binary"
+	yamlTagMerge     = "!!merge"
+)
+
+// FromYAML converts a YAML document into a value. ints of any size are kept exactly and timestamps become strings,
+// anything mconf can't represent (custom tags, binary data, non-scalar keys, infinite or NaN floats, multiple documents)
+// is reported as a *ConvertError. anchors, aliases and `<<` merge keys are expanded. an empty document is an empty object
+func FromYAML(data []byte) (parser.ParserValue, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var document yaml.Node
+
+	err := decoder.Decode(&document)
+	if errors.Is(err, io.EOF) {
+		return &parser.ParserValueObject{Value: parser.NewOrderedMap()}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var next yaml.Node
+
+	err = decoder.Decode(&next)
+	if err == nil {
+		return nil, yamlError(&next, "multiple documents aren't supported, split them into separate files")
+	}
+
+	if !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return fromYAMLNode(&document, map[*yaml.Node]bool{})
+}
+
+func yamlError(node *yaml.Node, format string, a ...any) error {
+	return &ConvertError{Format: "YAML", Line: node.Line, Col: node.Column, Message: fmt.Sprintf(format, a...)}
+}
+
+// visiting holds the aliased nodes currently being converted, an alias to one of them would never end
+func fromYAMLNode(node *yaml.Node, visiting map[*yaml.Node]bool) (parser.ParserValue, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return fromYAMLNode(node.Content[0], visiting)
+	case yaml.AliasNode:
+		if visiting[node.Alias] {
+			return nil, yamlError(node, "alias *%s refers to itself", node.Value)
+		}
+
+		visiting[node.Alias] = true
+		defer delete(visiting, node.Alias)
+
+		return fromYAMLNode(node.Alias, visiting)
+	case yaml.SequenceNode:
+		list := []parser.ParserValue{}
+
+		for _, item := range node.Content {
+			v, err := fromYAMLNode(item, visiting)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, v)
+		}
+
+		return &parser.ParserValueList{Value: list}, nil
+	case yaml.MappingNode:
+		return fromYAMLMapping(node, visiting)
+	default:
+		return fromYAMLScalar(node)
+	}
+}
+
+func fromYAMLMapping(node *yaml.Node, visiting map[*yaml.Node]bool) (parser.ParserValue, error) {
+	object := parser.NewOrderedMap()
+	// keys written out in the mapping itself win over the ones brought in by `<<`
+	explicit := map[string]bool{}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+
+		if keyNode.ShortTag() == yamlTagMerge {
+			continue
+		}
+
+		key, err := yamlKey(keyNode)
+		if err != nil {
+			return nil, err
+		}
+
+		if explicit[key] {
+			return nil, yamlError(keyNode, "duplicate key `%s`", key)
+		}
+
+		explicit[key] = true
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		if keyNode.ShortTag() == yamlTagMerge {
+			err := mergeYAML(object, explicit, valueNode, visiting)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		key, _ := yamlKey(keyNode)
+
+		value, err := fromYAMLNode(valueNode, visiting)
+		if err != nil {
+			return nil, err
+		}
+
+		object.Set(key, value)
+	}
+
+	return &parser.ParserValueObject{Value: object}, nil
+}
+
+// mergeYAML adds the keys of a `<<` merge key's mapping (or list of mappings, earlier ones winning) to object
+func mergeYAML(object *parser.OrderedMap, explicit map[string]bool, node *yaml.Node, visiting map[*yaml.Node]bool) error {
+	sources := []*yaml.Node{node}
+
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+
+	for _, source := range sources {
+		value, err := fromYAMLNode(source, visiting)
+		if err != nil {
+			return err
+		}
+
+		merged, err := value.GetObject()
+		if err != nil {
+			return yamlError(source, "`<<` can only merge mappings, got %s", value.GetType())
+		}
+
+		for _, k := range merged.Keys() {
+			if explicit[k] || object.Has(k) {
+				continue
+			}
+
+			v, _ := merged.Get(k)
+			object.Set(k, v)
+		}
+	}
+
+	return nil
+}
+
+func yamlKey(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return "", yamlError(node, "only scalars can be keys, mconf keys are strings")
+	}
+
+	return node.Value, nil
+}
+
+func fromYAMLScalar(node *yaml.Node) (parser.ParserValue, error) {
+	switch tag := node.ShortTag(); tag {
+	case yamlTagStr, yamlTagTimestamp:
+		return &parser.ParserValueString{Value: node.Value}, nil
+	case yamlTagNull:
+		return &parser.ParserValueNull{Value: true}, nil
+	case yamlTagBool:
+		var b bool
+
+		err := node.Decode(&b)
+		if err != nil {
+			return nil, yamlError(node, "invalid bool `%s`", node.Value)
+		}
+
+		return &parser.ParserValueBool{Value: b}, nil
+	case yamlTagFloat, yamlTagInt:
+		// the yaml package resolves ints that don't fit in an int64 as floats, they are still ints as long as no tag says otherwise
+		if tag == yamlTagFloat && (node.Style&yaml.TaggedStyle != 0 || !yamlInt.MatchString(node.Value)) {
+			return fromYAMLFloat(node)
+		}
+
+		i, ok := new(big.Int).SetString(node.Value, 0)
+		if !ok {
+			return nil, yamlError(node, "invalid int `%s`", node.Value)
+		}
+
+		return &parser.ParserValueInt{Value: i}, nil
+	case yamlTagBinary:
+		return nil, yamlError(node, "binary data has no mconf equivalent, use a base64 string instead")
+	default:
+		return nil, yamlError(node, "unsupported tag %s", tag)
+	}
+}
+
+func fromYAMLFloat(node *yaml.Node) (parser.ParserValue, error) {
+	special := strings.ToLower(strings.TrimLeft(node.Value, "+-"))
+
+	if special == ".inf" || special == ".nan" {
+		return nil, yamlError(node, "infinite and NaN floats have no mconf equivalent")
+	}
+
+	f, _, err := big.ParseFloat(node.Value, 0, parser.FLOAT_PRECISION, big.ToNearestEven)
+	if err != nil {
+		return nil, yamlError(node, "invalid float `%s`", node.Value)
+	}
+
+	return &parser.ParserValueFloat{Value: f}, nil
+}
+
+// yamlInt matches the ints of the YAML 1.2 core schema
+var yamlInt = regexp.MustCompile(`^([-+]?[0-9][0-9_]*|0x[0-9a-fA-F_]+|0o[0-7_]+|0b[01_]+)$`)
+
+// yamlAmbiguous matches strings that YAML 1.1 parsers read as something else (sexagesimal numbers like 1:30),
+// they are quoted even though YAML 1.2 doesn't require it
+var yamlAmbiguous = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+
+// ToYAML converts a value into a YAML document. strings are quoted whenever leaving them plain would make a YAML parser
+// read them as something else, including YAML 1.1 booleans like `yes` and `off`
+func ToYAML(v parser.ParserValue) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(toYAMLNode(v))
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func toYAMLNode(v parser.ParserValue) *yaml.Node {
+	switch v.GetType() {
+	case parser.PARSER_VALUE_TYPE_OBJECT:
+		obj, _ := v.GetObject()
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+		for _, k := range obj.Keys() {
+			item, _ := obj.Get(k)
+			node.Content = append(node.Content, yamlString(k), toYAMLNode(item))
+		}
+
+		return node
+	case parser.PARSER_VALUE_TYPE_LIST:
+		list, _ := v.GetList()
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for _, item := range list {
+			node.Content = append(node.Content, toYAMLNode(item))
+		}
+
+		return node
+	case parser.PARSER_VALUE_TYPE_STRING:
+		s, _ := v.GetString()
+		return yamlString(s)
+	case parser.PARSER_VALUE_TYPE_INT:
+		i, _ := v.GetInt()
+		// no tag, the yaml package would otherwise spell out `!!int` for ints that don't fit in an int64
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: i.String()}
+	case parser.PARSER_VALUE_TYPE_FLOAT:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlTagFloat, Value: v.ValueToString()}
+	case parser.PARSER_VALUE_TYPE_BOOL:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlTagBool, Value: v.ValueToString()}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlTagNull, Value: "null"}
+	}
+}
+
+func yamlString(s string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlTagStr, Value: s}
+
+	switch strings.ToLower(s) {
+	case "y", "yes", "n", "no", "on", "off":
+		node.Style = yaml.DoubleQuotedStyle
+	default:
+		if yamlAmbiguous.MatchString(s) {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+
+	return node
+}
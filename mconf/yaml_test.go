@@ -0,0 +1,115 @@
+package mconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+)
+
+func TestFromYAML(t *testing.T) {
+	src := `
+z: 1
+a:
+  big: 123456789012345678901234567890
+  hex: 0x1F
+  float: 1.5
+  exp: 1e3
+  "on": yes
+  null: ~
+  date: 2024-01-02
+defaults: &defaults
+  host: a
+  port: 1
+server:
+  <<: *defaults
+  port: 2
+list: [1, "2", true]
+`
+
+	value, err := FromYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{ z = 1, a = { big = 123456789012345678901234567890, hex = 31, float = 1.5, exp = 1000.0, "on" = "yes", "null" = null, date = "2024-01-02" }, defaults = { host = "a", port = 1 }, server = { host = "a", port = 2 }, list = [1, "2", true] }`
+
+	if got := value.ValueToString(); got != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	empty, err := FromYAML([]byte("# nothing\n"))
+	if err != nil || empty.ValueToString() != "{}" {
+		t.Errorf("expected an empty document to be an empty object, got %v, %v", empty, err)
+	}
+}
+
+func TestFromYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"multiple documents", "a: 1\n---\nb: 2"},
+		{"infinity", "a: .inf"},
+		{"nan", "a: .nan"},
+		{"binary", "a: !!binary aGk="},
+		{"custom tag", "a: !thing 1"},
+		{"non-scalar key", "? [1, 2]\n: 1"},
+		{"recursive alias", "a: &a [*a]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromYAML([]byte(tt.src))
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+
+			var convertErr *ConvertError
+			if !errors.As(err, &convertErr) {
+				t.Errorf("expected a *ConvertError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	src := `
+		strings = ["yes", "Off", "n", "true", "null", "~", "", " padded ", "1:30", "123", "0x1F", "1e3", "a: b", "#x", "- x", "multi\nline", "é"]
+		numbers = [0, -1, 123456789012345678901234567890, 0.1, 1.0e+300, -2.5]
+		other = { "a.b" = true, "" = null, nested = { list = [[1], {}] } }
+	`
+
+	value := parseObject(t, src)
+
+	out, err := ToYAML(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := FromYAML(out)
+	if err != nil {
+		t.Fatalf("unexpected error reading back:\n%s\n%v", out, err)
+	}
+
+	if !parser.Equal(value, back) {
+		t.Errorf("values changed, got %s from:\n%s", back.ValueToString(), out)
+	}
+}
+
+func TestToYAMLQuoting(t *testing.T) {
+	value := parseObject(t, `a = "yes"`+"\n"+`b = "1:30"`+"\n"+`c = "plain"`)
+
+	out, err := ToYAML(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// YAML 1.1 parsers read these as a bool and a number, so they're quoted even though YAML 1.2 doesn't need it
+	for _, line := range []string{`a: "yes"`, `b: "1:30"`, `c: plain`} {
+		if !strings.Contains(string(out), line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, out)
+		}
+	}
+}
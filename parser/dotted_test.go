@@ -0,0 +1,36 @@
+package parser
+
+import "testing"
+
+func TestDottedKeys(t *testing.T) {
+	expectValues(t, `
+		database.primary.host = "db1"
+		database.primary.port = 5432
+		database."replica set".hosts = ["db2", "db3"]
+		server = { http.port = 80, http.host = "a" }
+		server.tls.enabled = true
+		extended = { a = 1 }
+		extended.b = 2
+		replaced.a = 1
+		replaced = { b = 2 }
+		"quoted.key" = 1
+	`, map[string]string{
+		"database":   `{ primary = { host = "db1", port = 5432 }, "replica set" = { hosts = ["db2", "db3"] } }`,
+		"server":     `{ http = { port = 80, host = "a" }, tls = { enabled = true } }`,
+		"extended":   `{ a = 1, b = 2 }`,
+		"replaced":   `{ b = 2 }`,
+		"quoted.key": "1",
+	})
+}
+
+func TestDottedKeysThroughNonObject(t *testing.T) {
+	for _, src := range []string{
+		"a = 1\na.b = 2",
+		"a = [1]\na.b.c = 2",
+		"a = { b = \"x\" }\na.b.c = 2",
+	} {
+		if _, err := parseSource(t, src); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+// Equal reports whether two values are the same, objects are compared regardless of key order.
+// values of different types are never equal, not even an int and a float of the same value
+func Equal(a ParserValue, b ParserValue) bool {
+	if a.GetType() != b.GetType() {
+		return false
+	}
+
+	switch a.GetType() {
+	case PARSER_VALUE_TYPE_STRING:
+		as, _ := a.GetString()
+		bs, _ := b.GetString()
+
+		return as == bs
+	case PARSER_VALUE_TYPE_INT:
+		ai, _ := a.GetInt()
+		bi, _ := b.GetInt()
+
+		return ai.Cmp(bi) == 0
+	case PARSER_VALUE_TYPE_FLOAT:
+		af, _ := a.GetFloat()
+		bf, _ := b.GetFloat()
+
+		return af.Cmp(bf) == 0
+	case PARSER_VALUE_TYPE_BOOL:
+		ab, _ := a.GetBool()
+		bb, _ := b.GetBool()
+
+		return ab == bb
+	case PARSER_VALUE_TYPE_NULL:
+		return true
+	case PARSER_VALUE_TYPE_LIST:
+		al, _ := a.GetList()
+		bl, _ := b.GetList()
+
+		if len(al) != len(bl) {
+			return false
+		}
+
+		for i := range al {
+			if !Equal(al[i], bl[i]) {
+				return false
+			}
+		}
+
+		return true
+	case PARSER_VALUE_TYPE_OBJECT:
+		ao, _ := a.GetObject()
+		bo, _ := b.GetObject()
+
+		if ao.Len() != bo.Len() {
+			return false
+		}
+
+		for _, k := range ao.Keys() {
+			av, _ := ao.Get(k)
+
+			bv, ok := bo.Get(k)
+			if !ok || !Equal(av, bv) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
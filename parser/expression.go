@@ -0,0 +1,351 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// binaryPrecedence is how tightly a binary operator binds, higher binds tighter and 0 means the token isn't one
+func binaryPrecedence(token tokeniser.Token) int {
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_OR:
+		return 1
+	case tokeniser.TOKEN_TYPE_AND:
+		return 2
+	case tokeniser.TOKEN_TYPE_EQUAL, tokeniser.TOKEN_TYPE_NOT_EQUAL:
+		return 3
+	case tokeniser.TOKEN_TYPE_LESS, tokeniser.TOKEN_TYPE_LESS_EQUAL, tokeniser.TOKEN_TYPE_GREATER, tokeniser.TOKEN_TYPE_GREATER_EQUAL:
+		return 4
+	case tokeniser.TOKEN_TYPE_PLUS, tokeniser.TOKEN_TYPE_MINUS:
+		return 5
+	case tokeniser.TOKEN_TYPE_STAR, tokeniser.TOKEN_TYPE_SLASH, tokeniser.TOKEN_TYPE_PERCENT:
+		return 6
+	default:
+		return 0
+	}
+}
+
+func isOrdering(token tokeniser.Token) bool {
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_LESS, tokeniser.TOKEN_TYPE_LESS_EQUAL, tokeniser.TOKEN_TYPE_GREATER, tokeniser.TOKEN_TYPE_GREATER_EQUAL:
+		return true
+	default:
+		return false
+	}
+}
+
+// shortCircuits reports whether left alone decides the result of `&&` or `||`
+func shortCircuits(operator tokeniser.Token, left ParserValue) bool {
+	b, err := left.GetBool()
+	if err != nil {
+		return false
+	}
+
+	return (operator.Type == tokeniser.TOKEN_TYPE_AND && !b) || (operator.Type == tokeniser.TOKEN_TYPE_OR && b)
+}
+
+// ParseBinaryExpression parses operands joined by binary operators that bind at least as tightly as minPrecedence,
+// operators of the same precedence are evaluated left to right. the right side of a `&&` or `||` is skipped when
+// the left side already decides the result
+func (p *Parser) ParseBinaryExpression(minPrecedence int) (ParserValue, error) {
+	left, err := p.ParseUnaryExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator := p.Peek()
+		precedence := binaryPrecedence(operator)
+
+		if precedence == 0 || precedence < minPrecedence {
+			return left, nil
+		}
+
+		p.Increment()
+
+		parseRight := func() (ParserValue, error) {
+			return p.ParseBinaryExpression(precedence + 1)
+		}
+
+		right, err := p.parseMaybeSkipped(shortCircuits(operator, left), parseRight)
+		if err != nil {
+			return nil, err
+		}
+
+		if shortCircuits(operator, left) {
+			continue
+		}
+
+		left, err = p.EvaluateBinary(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ParseUnaryExpression parses an operand with any number of `-` and `!` in front of it
+func (p *Parser) ParseUnaryExpression() (ParserValue, error) {
+	operator := p.Peek()
+
+	if operator.Type != tokeniser.TOKEN_TYPE_MINUS && operator.Type != tokeniser.TOKEN_TYPE_NOT {
+		return p.ParseOperand()
+	}
+
+	p.Increment()
+
+	operand, err := p.ParseUnaryExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipping > 0 {
+		return &ParserValueNull{true}, nil
+	}
+
+	if operator.Type == tokeniser.TOKEN_TYPE_NOT {
+		b, err := operand.GetBool()
+		if err != nil {
+			return nil, p.TypeErrorAtToken(PARSER_VALUE_TYPE_BOOL, operand.GetType(), fmt.Sprintf("Operator `!` can't be used with a value of type %s, only with bools", strings.ToLower(operand.GetType())), operator.Start)
+		}
+
+		return &ParserValueBool{Value: !b}, nil
+	}
+
+	switch v := operand.(type) {
+	case *ParserValueInt:
+		return &ParserValueInt{Value: new(big.Int).Neg(v.Value)}, nil
+	case *ParserValueFloat:
+		return &ParserValueFloat{Value: new(big.Float).Neg(v.Value)}, nil
+	default:
+		return nil, p.TypeErrorAtToken(PARSER_VALUE_TYPE_INT, operand.GetType(), fmt.Sprintf("Operator `-` can't be used with a value of type %s, only with ints and floats", strings.ToLower(operand.GetType())), operator.Start)
+	}
+}
+
+func isNumber(v ParserValue) bool {
+	return v.GetType() == PARSER_VALUE_TYPE_INT || v.GetType() == PARSER_VALUE_TYPE_FLOAT
+}
+
+// EvaluateBinary applies a binary operator to two values. `+` also joins strings and lists, arithmetic on an int and a float
+// turns the int into a float and dividing ints rounds towards zero. ints and floats are compared by their value, strings
+// are ordered byte by byte and `==` tells any two values apart
+func (p *Parser) EvaluateBinary(operator tokeniser.Token, left ParserValue, right ParserValue) (ParserValue, error) {
+	if p.skipping > 0 {
+		return &ParserValueNull{true}, nil
+	}
+
+	switch operator.Type {
+	case tokeniser.TOKEN_TYPE_AND, tokeniser.TOKEN_TYPE_OR:
+		l, lerr := left.GetBool()
+		r, rerr := right.GetBool()
+
+		if lerr != nil || rerr != nil {
+			return nil, p.operatorTypeError(operator, left, right)
+		}
+
+		if operator.Type == tokeniser.TOKEN_TYPE_AND {
+			return &ParserValueBool{Value: l && r}, nil
+		}
+
+		return &ParserValueBool{Value: l || r}, nil
+	case tokeniser.TOKEN_TYPE_EQUAL, tokeniser.TOKEN_TYPE_NOT_EQUAL:
+		equal := Equal(left, right)
+
+		if isNumber(left) && isNumber(right) {
+			equal = compareNumbers(left, right) == 0
+		}
+
+		return &ParserValueBool{Value: equal == (operator.Type == tokeniser.TOKEN_TYPE_EQUAL)}, nil
+	}
+
+	if isOrdering(operator) {
+		var cmp int
+
+		switch {
+		case isNumber(left) && isNumber(right):
+			cmp = compareNumbers(left, right)
+		case left.GetType() == PARSER_VALUE_TYPE_STRING && right.GetType() == PARSER_VALUE_TYPE_STRING:
+			l, _ := left.GetString()
+			r, _ := right.GetString()
+
+			cmp = strings.Compare(l, r)
+		default:
+			return nil, p.operatorTypeError(operator, left, right)
+		}
+
+		switch operator.Type {
+		case tokeniser.TOKEN_TYPE_LESS:
+			return &ParserValueBool{Value: cmp < 0}, nil
+		case tokeniser.TOKEN_TYPE_LESS_EQUAL:
+			return &ParserValueBool{Value: cmp <= 0}, nil
+		case tokeniser.TOKEN_TYPE_GREATER:
+			return &ParserValueBool{Value: cmp > 0}, nil
+		default:
+			return &ParserValueBool{Value: cmp >= 0}, nil
+		}
+	}
+
+	if operator.Type == tokeniser.TOKEN_TYPE_PLUS && left.GetType() == right.GetType() {
+		switch left.GetType() {
+		case PARSER_VALUE_TYPE_STRING:
+			l, _ := left.GetString()
+			r, _ := right.GetString()
+
+			return &ParserValueString{Value: l + r}, nil
+		case PARSER_VALUE_TYPE_LIST:
+			l, _ := left.GetList()
+			r, _ := right.GetList()
+
+			joined := make([]ParserValue, 0, len(l)+len(r))
+			joined = append(joined, l...)
+			joined = append(joined, r...)
+
+			return &ParserValueList{Value: joined}, nil
+		}
+	}
+
+	if !isNumber(left) || !isNumber(right) {
+		return nil, p.operatorTypeError(operator, left, right)
+	}
+
+	l, lok := left.(*ParserValueInt)
+	r, rok := right.(*ParserValueInt)
+
+	if lok && rok {
+		return p.evaluateInt(operator, l.Value, r.Value)
+	}
+
+	return p.evaluateFloat(operator, toFloat(left), toFloat(right))
+}
+
+// compareNumbers compares two ints or floats, an int compared to a float is turned into one
+func compareNumbers(left ParserValue, right ParserValue) int {
+	l, lok := left.(*ParserValueInt)
+	r, rok := right.(*ParserValueInt)
+
+	if lok && rok {
+		return l.Value.Cmp(r.Value)
+	}
+
+	return toFloat(left).Cmp(toFloat(right))
+}
+
+func toFloat(v ParserValue) *big.Float {
+	switch n := v.(type) {
+	case *ParserValueInt:
+		return new(big.Float).SetInt(n.Value)
+	case *ParserValueFloat:
+		return n.Value
+	default:
+		return nil
+	}
+}
+
+func (p *Parser) evaluateInt(operator tokeniser.Token, l *big.Int, r *big.Int) (ParserValue, error) {
+	result := new(big.Int)
+
+	switch operator.Type {
+	case tokeniser.TOKEN_TYPE_PLUS:
+		result.Add(l, r)
+	case tokeniser.TOKEN_TYPE_MINUS:
+		result.Sub(l, r)
+	case tokeniser.TOKEN_TYPE_STAR:
+		result.Mul(l, r)
+	default:
+		if r.Sign() == 0 {
+			return nil, p.EvaluationErrorAtToken(diagnostics.CODE_DIVISION_BY_ZERO, fmt.Sprintf("Division by zero in `%s`", operator.Raw), operator.Start)
+		}
+
+		if operator.Type == tokeniser.TOKEN_TYPE_SLASH {
+			result.Quo(l, r)
+		} else {
+			result.Rem(l, r)
+		}
+	}
+
+	return &ParserValueInt{Value: result}, nil
+}
+
+func (p *Parser) evaluateFloat(operator tokeniser.Token, l *big.Float, r *big.Float) (ParserValue, error) {
+	result := new(big.Float)
+
+	switch operator.Type {
+	case tokeniser.TOKEN_TYPE_PLUS:
+		result.Add(l, r)
+	case tokeniser.TOKEN_TYPE_MINUS:
+		result.Sub(l, r)
+	case tokeniser.TOKEN_TYPE_STAR:
+		result.Mul(l, r)
+	default:
+		if r.Sign() == 0 {
+			return nil, p.EvaluationErrorAtToken(diagnostics.CODE_DIVISION_BY_ZERO, fmt.Sprintf("Division by zero in `%s`", operator.Raw), operator.Start)
+		}
+
+		if operator.Type == tokeniser.TOKEN_TYPE_PERCENT {
+			return &ParserValueFloat{Value: floatRem(l, r)}, nil
+		}
+
+		result.Quo(l, r)
+	}
+
+	return &ParserValueFloat{Value: result}, nil
+}
+
+// floatRem is l % r for floats, like with ints the remainder has the sign of l: it's what is left of l after taking away
+// r times the quotient rounded towards zero
+func floatRem(l *big.Float, r *big.Float) *big.Float {
+	prec := max(l.Prec(), r.Prec())
+
+	// enough precision for every bit of the integer part of the quotient and for the products and differences below to be exact
+	exact := l.Prec() + r.Prec() + uint(max(l.MantExp(nil)-r.MantExp(nil), 0)) + 2
+
+	quotient := new(big.Float).SetPrec(exact).SetMode(big.ToZero).Quo(l, r)
+	truncated, _ := quotient.Int(nil)
+
+	rem := new(big.Float).SetPrec(exact).SetInt(truncated)
+	rem.Mul(rem, r).Sub(l, rem)
+
+	return new(big.Float).SetPrec(prec).Set(rem)
+}
+
+// operatorTypeError reports the operand of a binary operator that has the wrong type
+func (p *Parser) operatorTypeError(operator tokeniser.Token, left ParserValue, right ParserValue) error {
+	var accepted string
+
+	switch {
+	case operator.Type == tokeniser.TOKEN_TYPE_PLUS:
+		accepted = "ints and floats, or two strings or two lists"
+	case operator.Type == tokeniser.TOKEN_TYPE_AND || operator.Type == tokeniser.TOKEN_TYPE_OR:
+		accepted = "bools"
+	case isOrdering(operator):
+		accepted = "ints and floats, or two strings"
+	default:
+		accepted = "ints and floats"
+	}
+
+	message := fmt.Sprintf("Operator `%s` can't be used with values of type %s and %s, only with %s", operator.Raw, strings.ToLower(left.GetType()), strings.ToLower(right.GetType()), accepted)
+
+	if operator.Type == tokeniser.TOKEN_TYPE_AND || operator.Type == tokeniser.TOKEN_TYPE_OR {
+		if left.GetType() != PARSER_VALUE_TYPE_BOOL {
+			return p.TypeErrorAtToken(PARSER_VALUE_TYPE_BOOL, left.GetType(), message, operator.Start)
+		}
+
+		return p.TypeErrorAtToken(PARSER_VALUE_TYPE_BOOL, right.GetType(), message, operator.Start)
+	}
+
+	// a string or a list on the left of `+` (or a string on the left of `<`) wants the same on the right, anything else wants numbers
+	if (operator.Type == tokeniser.TOKEN_TYPE_PLUS && left.GetType() == PARSER_VALUE_TYPE_LIST) || ((operator.Type == tokeniser.TOKEN_TYPE_PLUS || isOrdering(operator)) && left.GetType() == PARSER_VALUE_TYPE_STRING) {
+		return p.TypeErrorAtToken(left.GetType(), right.GetType(), message, operator.Start)
+	}
+
+	wrong := right
+
+	if !isNumber(left) {
+		wrong = left
+	}
+
+	return p.TypeErrorAtToken(PARSER_VALUE_TYPE_INT, wrong.GetType(), message, operator.Start)
+}
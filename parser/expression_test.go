@@ -0,0 +1,95 @@
+package parser
+
+import "testing"
+
+func TestRemainder(t *testing.T) {
+	expectValues(t, `
+		ints = -7 % 3
+		float = 10.0 % 3
+		negative = -7.5 % 2
+		mixed = 7 % 2.5
+		negative_divisor = 7.5 % -2
+	`, map[string]string{
+		"ints":             "-1",
+		"float":            "1.0",
+		"negative":         "-1.5",
+		"mixed":            "2.0",
+		"negative_divisor": "1.5",
+	})
+}
+
+func TestRemainderByZero(t *testing.T) {
+	for _, src := range []string{`a = 1 % 0`, `a = 1.5 % 0`, `a = 1 % 0.0`} {
+		if _, err := parseSource(t, src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	expectValues(t, `
+		$env = "prod"
+		$port = 8080
+
+		eq_mixed = 1 == 1.0
+		ne_types = 1 != "1"
+		eq_lists = [1, { a = 2 }] == [1, { a = 2 }]
+		ne_objects = { a = 1 } != { a = 1, b = 2 }
+		eq_null = null == null
+		lt = $port < 1024
+		le = 1.5 <= 2
+		big = 123456789012345678901234567890 > 123456789012345678901234567889
+		strings = "abc" < "abd"
+		ge_strings = "b" >= "abc"
+		precedence = 1 + 2 * 3 == 7 && 2 > 1
+		not = !($env == "prod")
+		level = $env == "prod" ~ "warn" | $env == "staging" ~ "info" | "debug"
+		or_first = true || false && false
+	`, map[string]string{
+		"eq_mixed":   "true",
+		"ne_types":   "true",
+		"eq_lists":   "true",
+		"ne_objects": "true",
+		"eq_null":    "true",
+		"lt":         "false",
+		"le":         "true",
+		"big":        "true",
+		"strings":    "true",
+		"ge_strings": "true",
+		"precedence": "true",
+		"not":        "false",
+		"level":      `"warn"`,
+		"or_first":   "true",
+	})
+}
+
+func TestShortCircuit(t *testing.T) {
+	// the right side isn't evaluated, so the undefined constants and the division by zero don't matter
+	expectValues(t, `
+		and = false && $undefined
+		or = true || 1 / 0 == 1
+		ternary = true ~ 1 | $undefined
+		nested = false && (true || $undefined)
+	`, map[string]string{
+		"and":     "false",
+		"or":      "true",
+		"ternary": "1",
+		"nested":  "false",
+	})
+}
+
+func TestComparisonTypeErrors(t *testing.T) {
+	for _, src := range []string{
+		`a = 1 < "2"`,
+		`a = [1] < [2]`,
+		`a = true > false`,
+		`a = 1 && true`,
+		`a = false || "x"`,
+		`a = !1`,
+		`a = 1 ~ 2 | 3`,
+	} {
+		if _, err := parseSource(t, src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
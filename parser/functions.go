@@ -0,0 +1,426 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// builtin is a function that can be called from a value
+type builtin struct {
+	// params holds the types each parameter accepts, a nil entry accepts any type. the last optional params can be left out
+	// and when variadic is set the last param can be repeated any number of times
+	params   [][]string
+	optional int
+	variadic bool
+
+	// call gets arguments that already have the right types, name is the token errors are reported at
+	call func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error)
+}
+
+var (
+	stringParam = []string{PARSER_VALUE_TYPE_STRING}
+	listParam   = []string{PARSER_VALUE_TYPE_LIST}
+	objectParam = []string{PARSER_VALUE_TYPE_OBJECT}
+	numberParam = []string{PARSER_VALUE_TYPE_INT, PARSER_VALUE_TYPE_FLOAT, PARSER_VALUE_TYPE_STRING}
+	anyParam    []string
+)
+
+var builtins = map[string]builtin{
+	"upper": stringFunction(strings.ToUpper),
+	"lower": stringFunction(strings.ToLower),
+	"trim":  stringFunction(strings.TrimSpace),
+	"replace": {params: [][]string{stringParam, stringParam, stringParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		return &ParserValueString{Value: strings.ReplaceAll(getString(args[0]), getString(args[1]), getString(args[2]))}, nil
+	}},
+	"split": {params: [][]string{stringParam, stringParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		parts := strings.Split(getString(args[0]), getString(args[1]))
+		list := make([]ParserValue, len(parts))
+
+		for i, part := range parts {
+			list[i] = &ParserValueString{Value: part}
+		}
+
+		return &ParserValueList{Value: list}, nil
+	}},
+	"join": {params: [][]string{listParam, stringParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		list, _ := args[0].GetList()
+		parts := make([]string, len(list))
+
+		for i, v := range list {
+			parts[i] = stringify(v)
+		}
+
+		return &ParserValueString{Value: strings.Join(parts, getString(args[1]))}, nil
+	}},
+	"format": {params: [][]string{stringParam, anyParam}, optional: 1, variadic: true, call: callFormat},
+
+	"base64":     stringFunction(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }),
+	"hex":        stringFunction(func(s string) string { return hex.EncodeToString([]byte(s)) }),
+	"url_escape": stringFunction(url.QueryEscape),
+	"sha256": stringFunction(func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}),
+
+	"len": {params: [][]string{{PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_OBJECT}}, call: callLen},
+	"keys": {params: [][]string{objectParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		obj, _ := args[0].GetObject()
+		keys := make([]ParserValue, 0, obj.Len())
+
+		for _, k := range obj.Keys() {
+			keys = append(keys, &ParserValueString{Value: k})
+		}
+
+		return &ParserValueList{Value: keys}, nil
+	}},
+	"values": {params: [][]string{objectParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		obj, _ := args[0].GetObject()
+		values := make([]ParserValue, 0, obj.Len())
+
+		for _, k := range obj.Keys() {
+			v, _ := obj.Get(k)
+			values = append(values, v)
+		}
+
+		return &ParserValueList{Value: values}, nil
+	}},
+	"contains": {params: [][]string{{PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_OBJECT}, anyParam}, call: callContains},
+	"merge": {params: [][]string{objectParam}, variadic: true, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		merged := args[0]
+
+		for _, v := range args[1:] {
+			merged = Merge(merged, v, MergeOptions{})
+		}
+
+		return merged, nil
+	}},
+
+	"int":    {params: [][]string{numberParam}, call: callInt},
+	"float":  {params: [][]string{numberParam}, call: callFloat},
+	"string": {params: [][]string{{PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_INT, PARSER_VALUE_TYPE_FLOAT, PARSER_VALUE_TYPE_BOOL, PARSER_VALUE_TYPE_NULL}}, call: callString},
+	"bool":   {params: [][]string{{PARSER_VALUE_TYPE_BOOL, PARSER_VALUE_TYPE_STRING}}, call: callBool},
+
+	"env": {params: [][]string{stringParam, anyParam}, optional: 1, call: callEnv},
+}
+
+func stringFunction(f func(string) string) builtin {
+	return builtin{params: [][]string{stringParam}, call: func(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+		return &ParserValueString{Value: f(getString(args[0]))}, nil
+	}}
+}
+
+func getString(v ParserValue) string {
+	s, _ := v.GetString()
+	return s
+}
+
+// stringify turns a value into a string the same way a string substitution does
+func stringify(v ParserValue) string {
+	if s, err := v.GetString(); err == nil {
+		return s
+	}
+
+	return v.ValueToString()
+}
+
+// ParseCall parses the arguments of a call to the built-in function name (the `(` is next) and calls it, errors about the
+// arguments are reported at the name of the function
+func (p *Parser) ParseCall(name tokeniser.Token) (ParserValue, error) {
+	f, ok := builtins[name.Value]
+	if !ok {
+		return nil, p.FormatErrorAtToken(diagnostics.CODE_UNKNOWN_FUNCTION, fmt.Sprintf("Unknown function `%s`", name.Value), name.Start)
+	}
+
+	p.Increment()
+
+	args := make([]ParserValue, 0)
+
+	for p.Peek().Type != tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+		arg, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+
+		commaOrClose := p.Peek()
+
+		if commaOrClose.Type == tokeniser.TOKEN_TYPE_COMMA {
+			p.Increment()
+		} else if commaOrClose.Type != tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing parenthesis `)`", commaOrClose.Start)
+		}
+	}
+
+	p.Increment()
+
+	if err := p.checkArgumentCount(name, f, len(args)); err != nil {
+		return nil, err
+	}
+
+	if p.skipping > 0 {
+		return &ParserValueNull{true}, nil
+	}
+
+	for i, arg := range args {
+		accepted := f.params[min(i, len(f.params)-1)]
+
+		if err := p.checkArgumentType(name, i, arg, accepted...); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.call(p, name, args)
+}
+
+func (p *Parser) checkArgumentCount(name tokeniser.Token, f builtin, count int) error {
+	required := len(f.params) - f.optional
+
+	var expected string
+
+	switch {
+	case f.variadic:
+		expected = fmt.Sprintf("at least %s", plural(required, "argument"))
+	case required == len(f.params):
+		expected = plural(required, "argument")
+	default:
+		expected = fmt.Sprintf("%d to %s", required, plural(len(f.params), "argument"))
+	}
+
+	if count < required || (!f.variadic && count > len(f.params)) {
+		return p.FormatErrorAtToken(diagnostics.CODE_ARGUMENT_COUNT, fmt.Sprintf("Function `%s` takes %s, got %d", name.Value, expected, count), name.Start)
+	}
+
+	return nil
+}
+
+// checkArgumentType returns an error when the i-th argument of a call isn't one of the accepted types, any type is fine when none are given
+func (p *Parser) checkArgumentType(name tokeniser.Token, i int, arg ParserValue, accepted ...string) error {
+	if len(accepted) == 0 {
+		return nil
+	}
+
+	for _, t := range accepted {
+		if arg.GetType() == t {
+			return nil
+		}
+	}
+
+	names := make([]string, len(accepted))
+
+	for j, t := range accepted {
+		names[j] = withArticle(strings.ToLower(t))
+	}
+
+	expected := names[0]
+
+	if len(names) > 1 {
+		expected = strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+	}
+
+	message := fmt.Sprintf("Argument %d of `%s` is a value of type %s, not %s", i+1, name.Value, strings.ToLower(arg.GetType()), expected)
+
+	return p.TypeErrorAtToken(accepted[0], arg.GetType(), message, name.Start)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func withArticle(word string) string {
+	if strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an " + word
+	}
+
+	return "a " + word
+}
+
+// callFormat replaces every `{}` in the format string with the next value
+func callFormat(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	format := getString(args[0])
+	values := args[1:]
+
+	placeholders := strings.Count(format, "{}")
+	if placeholders != len(values) {
+		return nil, p.EvaluationErrorAtToken(diagnostics.CODE_ARGUMENT_COUNT, fmt.Sprintf("Format string of `%s` has %s, got %s", name.Value, plural(placeholders, "placeholder"), plural(len(values), "value")), name.Start)
+	}
+
+	var sb strings.Builder
+
+	for i, part := range strings.Split(format, "{}") {
+		sb.WriteString(part)
+
+		if i < len(values) {
+			sb.WriteString(stringify(values[i]))
+		}
+	}
+
+	return &ParserValueString{Value: sb.String()}, nil
+}
+
+// callLen counts the characters of a string, the elements of a list or the keys of an object
+func callLen(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	var n int
+
+	switch v := args[0].(type) {
+	case *ParserValueString:
+		n = utf8.RuneCountInString(v.Value)
+	case *ParserValueList:
+		n = len(v.Value)
+	case *ParserValueObject:
+		n = v.Value.Len()
+	}
+
+	return &ParserValueInt{Value: big.NewInt(int64(n))}, nil
+}
+
+// callContains looks for a substring in a string, an element in a list or a key in an object
+func callContains(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	switch v := args[0].(type) {
+	case *ParserValueList:
+		for _, element := range v.Value {
+			if Equal(element, args[1]) {
+				return &ParserValueBool{Value: true}, nil
+			}
+		}
+
+		return &ParserValueBool{Value: false}, nil
+	case *ParserValueObject:
+		if err := p.checkArgumentType(name, 1, args[1], PARSER_VALUE_TYPE_STRING); err != nil {
+			return nil, err
+		}
+
+		return &ParserValueBool{Value: v.Value.Has(getString(args[1]))}, nil
+	default:
+		if err := p.checkArgumentType(name, 1, args[1], PARSER_VALUE_TYPE_STRING); err != nil {
+			return nil, err
+		}
+
+		return &ParserValueBool{Value: strings.Contains(getString(args[0]), getString(args[1]))}, nil
+	}
+}
+
+func (p *Parser) conversionError(name tokeniser.Token, v ParserValue, target string) error {
+	return p.EvaluationErrorAtToken(diagnostics.CODE_INVALID_CONVERSION, fmt.Sprintf("Can't convert %s to %s", v.ValueToString(), strings.ToLower(target)), name.Start)
+}
+
+// callInt converts a float (rounding towards zero) or a string holding a number to an int
+func callInt(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	switch v := args[0].(type) {
+	case *ParserValueInt:
+		return v, nil
+	case *ParserValueFloat:
+		if v.Value.IsInf() {
+			return nil, p.conversionError(name, v, PARSER_VALUE_TYPE_INT)
+		}
+
+		i, _ := v.Value.Int(nil)
+		return &ParserValueInt{Value: i}, nil
+	default:
+		s := strings.TrimSpace(getString(v))
+
+		if i, ok := parseIntString(s); ok {
+			return &ParserValueInt{Value: i}, nil
+		}
+
+		f, _, err := big.ParseFloat(s, 10, FLOAT_PRECISION, big.ToNearestEven)
+		if err != nil || f.IsInf() {
+			return nil, p.conversionError(name, v, PARSER_VALUE_TYPE_INT)
+		}
+
+		i, _ := f.Int(nil)
+		return &ParserValueInt{Value: i}, nil
+	}
+}
+
+// parseIntString reads a decimal int, or a hex or binary one with a `0x` or `0b` prefix. a leading zero doesn't make it octal
+func parseIntString(s string) (*big.Int, bool) {
+	sign := ""
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	base := 10
+
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		base, s = 16, s[2:]
+	case strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B"):
+		base, s = 2, s[2:]
+	}
+
+	// SetString would take a second sign after the prefix
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return nil, false
+	}
+
+	return new(big.Int).SetString(sign+s, base)
+}
+
+// callFloat converts an int or a string holding a number to a float
+func callFloat(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	switch v := args[0].(type) {
+	case *ParserValueFloat:
+		return v, nil
+	case *ParserValueInt:
+		return &ParserValueFloat{Value: toFloat(v)}, nil
+	default:
+		f, _, err := big.ParseFloat(strings.TrimSpace(getString(v)), 10, FLOAT_PRECISION, big.ToNearestEven)
+		if err != nil {
+			return nil, p.conversionError(name, v, PARSER_VALUE_TYPE_FLOAT)
+		}
+
+		return &ParserValueFloat{Value: f}, nil
+	}
+}
+
+// callString writes a value the way it would appear in a string substitution
+func callString(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	return &ParserValueString{Value: stringify(args[0])}, nil
+}
+
+// callBool converts the strings that are bool literals (`"true"`, `"yes"`, `"off"`...) to a bool
+func callBool(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	if args[0].GetType() == PARSER_VALUE_TYPE_BOOL {
+		return args[0], nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(getString(args[0]))) {
+	case "true", "yes", "on":
+		return &ParserValueBool{Value: true}, nil
+	case "false", "no", "off":
+		return &ParserValueBool{Value: false}, nil
+	default:
+		return nil, p.conversionError(name, args[0], PARSER_VALUE_TYPE_BOOL)
+	}
+}
+
+// callEnv reads an environment variable, falling back to the default when it isn't set. unlike a constant it
+// is never shadowed by a constant of the same name
+func callEnv(p *Parser, name tokeniser.Token, args []ParserValue) (ParserValue, error) {
+	variable := getString(args[0])
+
+	if v, ok := p.env.get(variable); ok {
+		return v, nil
+	}
+
+	if len(args) > 1 {
+		return args[1], nil
+	}
+
+	return nil, p.ConstantNotFoundError(variable, fmt.Sprintf("Environment variable `%s` not set", variable), name.Start)
+}
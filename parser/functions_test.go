@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// parseSource parses src without an environment and returns its values
+func parseSource(t *testing.T, src string) (*OrderedMap, error) {
+	t.Helper()
+
+	tok := tokeniser.NewTokeniser(src, "test.mconf", "")
+
+	tokens, err := tok.Tokenise()
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewParser(tokens, t.TempDir(), "test.mconf", "")
+	p.SetEnv(map[string]string{})
+
+	return p.Parse()
+}
+
+// expectValues parses src and checks the printed value of every key in expected
+func expectValues(t *testing.T, src string, expected map[string]string) {
+	t.Helper()
+
+	values, err := parseSource(t, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for key, want := range expected {
+		v, ok := values.Get(key)
+		if !ok {
+			t.Errorf("%s: not set", key)
+			continue
+		}
+
+		if got := v.ValueToString(); got != want {
+			t.Errorf("%s = %s, expected %s", key, got, want)
+		}
+	}
+}
+
+func TestIntConversion(t *testing.T) {
+	expectValues(t, `
+		leading_zero = int("010")
+		nine = int("09")
+		negative = int("-42")
+		hex = int("0x1f")
+		binary = int("0b101")
+		float = int("2.9")
+	`, map[string]string{
+		"leading_zero": "10",
+		"nine":         "9",
+		"negative":     "-42",
+		"hex":          "31",
+		"binary":       "5",
+		"float":        "2",
+	})
+}
+
+func TestIntConversionInvalid(t *testing.T) {
+	for _, src := range []string{`a = int("abc")`, `a = int("0x")`, `a = int("0x-1")`} {
+		if _, err := parseSource(t, src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	expectValues(t, `
+		$name = "  Web Server "
+		$ports = [80, 443]
+
+		id = lower(replace(trim($name), " ", "-"))
+		upper = upper("é")
+		split = split("a,b,,c", ",")
+		listen = join($ports, ",")
+		format = format("{}:{} {}", "localhost", 8080, [1])
+		base64 = base64("user:pass")
+		hex = hex("hi")
+		url = url_escape("a b&c")
+		sha = sha256("")
+		len_string = len("héllo")
+		len_list = len($ports)
+		len_object = len({ a = 1, b = 2 })
+		keys = keys({ b = 1, a = 2 })
+		values = values({ b = 1, a = 2 })
+		has_element = contains($ports, 443)
+		has_substring = contains("hello", "ell")
+		has_key = contains({ a = 1 }, "b")
+		merge = merge({ a = { x = 1 }, l = [1] }, { a = { y = 2 }, l = [2] }, { b = 3 })
+		float = float("1.5")
+		float_int = float(2)
+		int_float = int(-2.9)
+		string = string(1.5)
+		bool = bool("Off")
+		nested = len(split(upper("a-b"), "-")) == 2
+	`, map[string]string{
+		"id":            `"web-server"`,
+		"upper":         `"É"`,
+		"split":         `["a", "b", "", "c"]`,
+		"listen":        `"80,443"`,
+		"format":        `"localhost:8080 [1]"`,
+		"base64":        `"dXNlcjpwYXNz"`,
+		"hex":           `"6869"`,
+		"url":           `"a+b%26c"`,
+		"sha":           `"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`,
+		"len_string":    "5",
+		"len_list":      "2",
+		"len_object":    "2",
+		"keys":          `["b", "a"]`,
+		"values":        "[1, 2]",
+		"has_element":   "true",
+		"has_substring": "true",
+		"has_key":       "false",
+		"merge":         "{ a = { x = 1, y = 2 }, l = [2], b = 3 }",
+		"float":         "1.5",
+		"float_int":     "2.0",
+		"int_float":     "-2",
+		"string":        `"1.5"`,
+		"bool":          "false",
+		"nested":        "true",
+	})
+}
+
+func TestEnvFunction(t *testing.T) {
+	tok := tokeniser.NewTokeniser(`
+		$HOME = "constant"
+		home = env("HOME")
+		missing = env("MISSING", "default")
+	`, "test.mconf", "")
+
+	tokens, err := tok.Tokenise()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(tokens, t.TempDir(), "test.mconf", "")
+	p.SetEnv(map[string]string{"HOME": "/home/user"})
+
+	values, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// env ignores constants, even ones with the same name
+	for key, want := range map[string]string{"home": `"/home/user"`, "missing": `"default"`} {
+		if v, _ := values.Get(key); v == nil || v.ValueToString() != want {
+			t.Errorf("%s = %v, expected %s", key, v, want)
+		}
+	}
+
+	if _, err := parseSource(t, `a = env("MISSING")`); err == nil {
+		t.Error("expected an error for a missing variable without a default")
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		code string
+	}{
+		{`a = nope(1)`, diagnostics.CODE_UNKNOWN_FUNCTION},
+		{`a = upper()`, diagnostics.CODE_ARGUMENT_COUNT},
+		{`a = upper("a", "b")`, diagnostics.CODE_ARGUMENT_COUNT},
+		{`a = replace("a", "b")`, diagnostics.CODE_ARGUMENT_COUNT},
+		{`a = upper(1)`, diagnostics.CODE_TYPE_MISMATCH},
+		{`a = join("a", ",")`, diagnostics.CODE_TYPE_MISMATCH},
+		{`a = keys([1])`, diagnostics.CODE_TYPE_MISMATCH},
+		{`a = merge({ a = 1 }, [1])`, diagnostics.CODE_TYPE_MISMATCH},
+		{`a = float("x")`, diagnostics.CODE_INVALID_CONVERSION},
+		{`a = bool("maybe")`, diagnostics.CODE_INVALID_CONVERSION},
+		{`a = string([1])`, diagnostics.CODE_TYPE_MISMATCH},
+	}
+
+	for _, tt := range tests {
+		_, err := parseSource(t, tt.src)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.src)
+			continue
+		}
+
+		if code := errorCode(err); code != tt.code {
+			t.Errorf("%s: expected %s, got %s: %v", tt.src, tt.code, code, err)
+		}
+	}
+}
+
+// errorCode returns the code of the first error in err
+func errorCode(err error) string {
+	if list, ok := err.(diagnostics.List); ok && len(list) > 0 {
+		err = list[0]
+	}
+
+	switch e := err.(type) {
+	case *diagnostics.SyntaxError:
+		return e.Code
+	case *diagnostics.TypeError:
+		return e.Code
+	case *diagnostics.EvaluationError:
+		return e.Code
+	case *diagnostics.ConstantNotFoundError:
+		return e.Code
+	case *diagnostics.ImportError:
+		return e.Code
+	default:
+		return ""
+	}
+}
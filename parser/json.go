@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// JSONOptions controls how a JSONEncoder prints values, the zero value prints compact JSON with keys in definition order
+type JSONOptions struct {
+	// Indent is how many spaces nested values are indented with, 0 prints everything on one line
+	Indent int
+	// SortKeys prints the keys of objects sorted instead of in the order they were defined in
+	SortKeys bool
+	// ASCII escapes every non-ASCII character as \uXXXX
+	ASCII bool
+}
+
+// JSONEncoder writes values as JSON to a writer as it walks them, without building the whole output in memory
+type JSONEncoder struct {
+	w    *bufio.Writer
+	opts JSONOptions
+}
+
+func NewJSONEncoder(w io.Writer, opts JSONOptions) *JSONEncoder {
+	return &JSONEncoder{
+		w:    bufio.NewWriter(w),
+		opts: opts,
+	}
+}
+
+// Encode writes v followed by a newline
+func (e *JSONEncoder) Encode(v ParserValue) error {
+	e.value(v, 0)
+	e.w.WriteByte('\n')
+
+	return e.w.Flush()
+}
+
+// EncodeLines writes every element of a list on a line of its own (newline delimited JSON), ignoring Indent,
+// any other value is written on a single line
+func (e *JSONEncoder) EncodeLines(v ParserValue) error {
+	compact := &JSONEncoder{w: e.w, opts: e.opts}
+	compact.opts.Indent = 0
+
+	if v.GetType() != PARSER_VALUE_TYPE_LIST {
+		return compact.Encode(v)
+	}
+
+	list, err := v.GetList()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range list {
+		compact.value(item, 0)
+		e.w.WriteByte('\n')
+	}
+
+	return e.w.Flush()
+}
+
+// jsonString is the compact encoding of v, what ToJSONString returns
+func jsonString(v ParserValue) string {
+	sb := strings.Builder{}
+
+	e := NewJSONEncoder(&sb, JSONOptions{})
+	e.value(v, 0)
+	e.w.Flush()
+
+	return sb.String()
+}
+
+func (e *JSONEncoder) newline(depth int) {
+	if e.opts.Indent <= 0 {
+		return
+	}
+
+	e.w.WriteByte('\n')
+	e.w.WriteString(strings.Repeat(" ", e.opts.Indent*depth))
+}
+
+func (e *JSONEncoder) value(v ParserValue, depth int) {
+	switch v.GetType() {
+	case PARSER_VALUE_TYPE_OBJECT:
+		obj, _ := v.GetObject()
+		keys := obj.Keys()
+
+		if len(keys) == 0 {
+			e.w.WriteString("{}")
+			return
+		}
+
+		if e.opts.SortKeys {
+			sort.Strings(keys)
+		}
+
+		e.w.WriteByte('{')
+
+		for i, k := range keys {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+
+			e.newline(depth + 1)
+			e.string(k)
+			e.w.WriteByte(':')
+
+			if e.opts.Indent > 0 {
+				e.w.WriteByte(' ')
+			}
+
+			item, _ := obj.Get(k)
+			e.value(item, depth+1)
+		}
+
+		e.newline(depth)
+		e.w.WriteByte('}')
+	case PARSER_VALUE_TYPE_LIST:
+		list, _ := v.GetList()
+
+		if len(list) == 0 {
+			e.w.WriteString("[]")
+			return
+		}
+
+		e.w.WriteByte('[')
+
+		for i, item := range list {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+
+			e.newline(depth + 1)
+			e.value(item, depth+1)
+		}
+
+		e.newline(depth)
+		e.w.WriteByte(']')
+	case PARSER_VALUE_TYPE_STRING:
+		s, _ := v.GetString()
+		e.string(s)
+	case PARSER_VALUE_TYPE_INT:
+		i, _ := v.GetInt()
+		e.w.WriteString(i.String())
+	default:
+		// floats, bools and null are already spelled the same in mconf and JSON
+		e.w.WriteString(v.ValueToString())
+	}
+}
+
+func (e *JSONEncoder) string(s string) {
+	e.w.WriteByte('"')
+
+	for _, c := range s {
+		switch c {
+		case '\\':
+			e.w.WriteString("\\\\")
+		case '"':
+			e.w.WriteString("\\\"")
+		case '\b':
+			e.w.WriteString("\\b")
+		case '\t':
+			e.w.WriteString("\\t")
+		case '\n':
+			e.w.WriteString("\\n")
+		case '\f':
+			e.w.WriteString("\\f")
+		case '\r':
+			e.w.WriteString("\\r")
+		default:
+			switch {
+			case c < 0x20:
+				fmt.Fprintf(e.w, "\\u%04x", c)
+			case e.opts.ASCII && c > 0x7e:
+				if c > 0xffff {
+					high, low := utf16.EncodeRune(c)
+					fmt.Fprintf(e.w, "\\u%04x\\u%04x", high, low)
+				} else {
+					fmt.Fprintf(e.w, "\\u%04x", c)
+				}
+			default:
+				e.w.WriteRune(c)
+			}
+		}
+	}
+
+	e.w.WriteByte('"')
+}
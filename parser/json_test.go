@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const jsonSource = `
+	b = "é\t\"😀"
+	a = [1, 0.5, null]
+	c = { z = true, y = {} }
+	d = []
+	big = 123456789012345678901234567890
+`
+
+func TestJSONEncoder(t *testing.T) {
+	values, err := parseSource(t, jsonSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     JSONOptions
+		expected string
+	}{
+		{
+			"compact",
+			JSONOptions{},
+			`{"b":"é\t\"😀","a":[1,0.5,null],"c":{"z":true,"y":{}},"d":[],"big":123456789012345678901234567890}`,
+		},
+		{
+			"indent",
+			JSONOptions{Indent: 2},
+			"{\n  \"b\": \"é\\t\\\"😀\",\n  \"a\": [\n    1,\n    0.5,\n    null\n  ],\n  \"c\": {\n    \"z\": true,\n    \"y\": {}\n  },\n  \"d\": [],\n  \"big\": 123456789012345678901234567890\n}",
+		},
+		{
+			"sorted keys",
+			JSONOptions{SortKeys: true},
+			`{"a":[1,0.5,null],"b":"é\t\"😀","big":123456789012345678901234567890,"c":{"y":{},"z":true},"d":[]}`,
+		},
+		{
+			"ascii",
+			JSONOptions{ASCII: true},
+			`{"b":"\u00e9\t\"\ud83d\ude00","a":[1,0.5,null],"c":{"z":true,"y":{}},"d":[],"big":123456789012345678901234567890}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := strings.Builder{}
+
+			if err := NewJSONEncoder(&sb, tt.opts).Encode(&ParserValueObject{Value: values}); err != nil {
+				t.Fatal(err)
+			}
+
+			out := sb.String()
+
+			if out != tt.expected+"\n" {
+				t.Errorf("got:\n%s\nexpected:\n%s", out, tt.expected)
+			}
+
+			if !json.Valid([]byte(out)) {
+				t.Errorf("output isn't valid JSON: %s", out)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderLines(t *testing.T) {
+	values, err := parseSource(t, `list = [{ a = 1 }, "x", [1, 2]]`+"\n"+`object = { a = 1 }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sb := strings.Builder{}
+	encoder := NewJSONEncoder(&sb, JSONOptions{Indent: 4})
+
+	list, _ := values.Get("list")
+	object, _ := values.Get("object")
+
+	if err := encoder.EncodeLines(list); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := encoder.EncodeLines(object); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "{\"a\":1}\n\"x\"\n[1,2]\n{\"a\":1}\n"; sb.String() != expected {
+		t.Errorf("got %q, expected %q", sb.String(), expected)
+	}
+}
@@ -0,0 +1,244 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// KeyNotFoundError is returned when an object has no value under the looked up key
+type KeyNotFoundError struct {
+	Path string
+	Key  string
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("Property %s not found", e.Path)
+}
+
+// NotContainerError is returned when a path continues past a value that is not an object or a list
+type NotContainerError struct {
+	Path string
+	Type string
+}
+
+func (e *NotContainerError) Error() string {
+	return fmt.Sprintf("Property %s not found, indexed value is not an object or list (it is of type %s)", e.Path, e.Type)
+}
+
+// InvalidIndexError is returned when a list is indexed with something that is not an integer
+type InvalidIndexError struct {
+	Path  string
+	Index string
+}
+
+func (e *InvalidIndexError) Error() string {
+	return fmt.Sprintf("Property %s not found, index is not an integer", e.Path)
+}
+
+// IndexOutOfRangeError is returned when a list index is negative or past the end of the list
+type IndexOutOfRangeError struct {
+	Path   string
+	Index  int
+	Length int
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("Property %s not found, index %d out of bounds for list of length %d", e.Path, e.Index, e.Length)
+}
+
+// ParsePath splits a path expression like `servers[2].tls."cert file"` into its segments
+func ParsePath(expr string) ([]string, error) {
+	segments := []string{}
+	rs := []rune(expr)
+	i := 0
+
+	readQuoted := func() (string, error) {
+		sb := strings.Builder{}
+		i++
+
+		for {
+			if i >= len(rs) {
+				return "", fmt.Errorf("Invalid path `%s`: unterminated quoted key", expr)
+			}
+
+			c := rs[i]
+			i++
+
+			if c == '"' {
+				return sb.String(), nil
+			}
+
+			if c == '\\' && i < len(rs) {
+				c = rs[i]
+				i++
+			}
+
+			sb.WriteRune(c)
+		}
+	}
+
+	if len(rs) == 0 {
+		return segments, nil
+	}
+
+	expectSegment := true
+
+	for i < len(rs) {
+		c := rs[i]
+
+		switch {
+		case c == '[':
+			i++
+
+			if i < len(rs) && rs[i] == '"' {
+				key, err := readQuoted()
+				if err != nil {
+					return nil, err
+				}
+
+				segments = append(segments, key)
+			} else {
+				start := i
+
+				for i < len(rs) && rs[i] != ']' {
+					i++
+				}
+
+				index := strings.TrimSpace(string(rs[start:i]))
+				if index == "" {
+					return nil, fmt.Errorf("Invalid path `%s`: empty index", expr)
+				}
+
+				segments = append(segments, index)
+			}
+
+			if i >= len(rs) || rs[i] != ']' {
+				return nil, fmt.Errorf("Invalid path `%s`: expected `]`", expr)
+			}
+
+			i++
+			expectSegment = false
+		case c == '.':
+			if expectSegment {
+				return nil, fmt.Errorf("Invalid path `%s`: empty key", expr)
+			}
+
+			i++
+			expectSegment = true
+		case expectSegment && c == '"':
+			key, err := readQuoted()
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, key)
+			expectSegment = false
+		case expectSegment:
+			start := i
+
+			for i < len(rs) && rs[i] != '.' && rs[i] != '[' {
+				i++
+			}
+
+			segments = append(segments, string(rs[start:i]))
+			expectSegment = false
+		default:
+			return nil, fmt.Errorf("Invalid path `%s`: unexpected `%c`", expr, c)
+		}
+	}
+
+	if expectSegment {
+		return nil, fmt.Errorf("Invalid path `%s`: empty key", expr)
+	}
+
+	return segments, nil
+}
+
+// FormatPath joins segments back into a path expression
+func FormatPath(segments []string) string {
+	s := ""
+
+	for _, seg := range segments {
+		s = AppendPathKey(s, seg)
+	}
+
+	return s
+}
+
+// AppendPathKey adds an object key to a path the way paths are printed in errors, quoting the key when it needs it
+func AppendPathKey(path string, key string) string {
+	if key == "" || (!tokeniser.IsLegalWord([]rune(key)) && !isIndex(key)) {
+		key = fmt.Sprintf("\"%s\"", strings.ReplaceAll(strings.ReplaceAll(key, "\\", "\\\\"), "\"", "\\\""))
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func isIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// Lookup finds the value at a path expression, see ParsePath for the syntax
+func Lookup(root ParserValue, expr string) (ParserValue, error) {
+	path, err := ParsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return LookupPath(root, path)
+}
+
+// LookupPath walks root one segment at a time, segments index objects by key and lists by integer index
+func LookupPath(root ParserValue, path []string) (ParserValue, error) {
+	current := root
+	walked := ""
+
+	for _, seg := range path {
+		switch current.GetType() {
+		case PARSER_VALUE_TYPE_OBJECT:
+			obj, err := current.GetObject()
+			if err != nil {
+				return nil, err
+			}
+
+			walked = AppendPathKey(walked, seg)
+
+			next, ok := obj.Get(seg)
+			if !ok {
+				return nil, &KeyNotFoundError{Path: walked, Key: seg}
+			}
+
+			current = next
+		case PARSER_VALUE_TYPE_LIST:
+			list, err := current.GetList()
+			if err != nil {
+				return nil, err
+			}
+
+			walked = fmt.Sprintf("%s[%s]", walked, seg)
+
+			index, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, &InvalidIndexError{Path: walked, Index: seg}
+			}
+
+			if index < 0 || index >= len(list) {
+				return nil, &IndexOutOfRangeError{Path: walked, Index: index, Length: len(list)}
+			}
+
+			current = list[index]
+		default:
+			return nil, &NotContainerError{Path: AppendPathKey(walked, seg), Type: current.GetType()}
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"a", []string{"a"}},
+		{"servers[2].tls.cert", []string{"servers", "2", "tls", "cert"}},
+		{`"a.b".c`, []string{"a.b", "c"}},
+		{`a["b c"][0]`, []string{"a", "b c", "0"}},
+		{`"q\"uote"`, []string{`q"uote`}},
+		{"[ 1 ]", []string{"1"}},
+		{"é.ü", []string{"é", "ü"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePath(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: got %q, expected %q", tt.expr, got, tt.expected)
+		}
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, expr := range []string{".a", "a.", "a..b", "a[]", "a[1", `"a`, `a["b`, "a[0]b", `"a"b`} {
+		if _, err := ParsePath(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+	for _, segments := range [][]string{{"a", "b"}, {"a.b", "", "c d"}, {`q"uote\`, "0"}} {
+		got, err := ParsePath(FormatPath(segments))
+		if err != nil || !reflect.DeepEqual(got, segments) {
+			t.Errorf("%q printed as %s read back as %q (%v)", segments, FormatPath(segments), got, err)
+		}
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	values, err := parseSource(t, `servers = [{ host = "a" }, { "my key" = 1 }]`+"\nname = \"x\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := &ParserValueObject{Value: values}
+
+	v, err := Lookup(root, `servers[1]."my key"`)
+	if err != nil || v.ValueToString() != "1" {
+		t.Errorf("got %v, %v", v, err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected error
+	}{
+		{"servers[0].port", &KeyNotFoundError{Path: "servers[0].port", Key: "port"}},
+		{"name.first", &NotContainerError{Path: "name.first", Type: PARSER_VALUE_TYPE_STRING}},
+		{"servers.host", &InvalidIndexError{Path: "servers[host]", Index: "host"}},
+		{"servers[2]", &IndexOutOfRangeError{Path: "servers[2]", Index: 2, Length: 2}},
+		{"servers[-1]", &IndexOutOfRangeError{Path: "servers[-1]", Index: -1, Length: 2}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Lookup(root, tt.expr); !reflect.DeepEqual(err, tt.expected) {
+			t.Errorf("%s: got %#v, expected %#v", tt.expr, err, tt.expected)
+		}
+	}
+}
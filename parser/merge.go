@@ -0,0 +1,152 @@
+package parser
+
+import "fmt"
+
+const (
+	MERGE_LISTS_REPLACE       = "replace"
+	MERGE_LISTS_APPEND        = "append"
+	MERGE_LISTS_UNIQUE_APPEND = "unique-append"
+)
+
+// MergeOptions controls how Merge combines values
+type MergeOptions struct {
+	// Lists is what happens when both sides have a list under the same key: MERGE_LISTS_REPLACE (the default) keeps the
+	// override's list, MERGE_LISTS_APPEND adds its elements to the base list and MERGE_LISTS_UNIQUE_APPEND only adds
+	// the elements the base list doesn't have yet
+	Lists string
+}
+
+// ValidateMergeOptions returns an error for strategies Merge doesn't know
+func ValidateMergeOptions(opts MergeOptions) error {
+	switch opts.Lists {
+	case "", MERGE_LISTS_REPLACE, MERGE_LISTS_APPEND, MERGE_LISTS_UNIQUE_APPEND:
+		return nil
+	default:
+		return fmt.Errorf("Unknown list merge strategy `%s`, expected %s, %s or %s", opts.Lists, MERGE_LISTS_REPLACE, MERGE_LISTS_APPEND, MERGE_LISTS_UNIQUE_APPEND)
+	}
+}
+
+// Merge deep merges override into base and returns the result, neither of them is modified. objects are merged key by key
+// (keys only in the override go after the base's keys), lists are combined according to opts and anything else is replaced
+func Merge(base ParserValue, override ParserValue, opts MergeOptions) ParserValue {
+	switch {
+	case base.GetType() == PARSER_VALUE_TYPE_OBJECT && override.GetType() == PARSER_VALUE_TYPE_OBJECT:
+		baseObj, _ := base.GetObject()
+		overrideObj, _ := override.GetObject()
+
+		return &ParserValueObject{Value: MergeObjects(baseObj, overrideObj, opts)}
+	case base.GetType() == PARSER_VALUE_TYPE_LIST && override.GetType() == PARSER_VALUE_TYPE_LIST:
+		baseList, _ := base.GetList()
+		overrideList, _ := override.GetList()
+
+		return &ParserValueList{Value: mergeLists(baseList, overrideList, opts)}
+	default:
+		return override
+	}
+}
+
+// MergeObjects is Merge for the maps of two objects
+func MergeObjects(base *OrderedMap, override *OrderedMap, opts MergeOptions) *OrderedMap {
+	merged := base.Copy()
+
+	for _, k := range override.Keys() {
+		v, _ := override.Get(k)
+
+		if existing, ok := merged.Get(k); ok {
+			v = Merge(existing, v, opts)
+		}
+
+		merged.Set(k, v)
+	}
+
+	return merged
+}
+
+func mergeLists(base []ParserValue, override []ParserValue, opts MergeOptions) []ParserValue {
+	switch opts.Lists {
+	case MERGE_LISTS_APPEND:
+		return append(append([]ParserValue{}, base...), override...)
+	case MERGE_LISTS_UNIQUE_APPEND:
+		merged := append([]ParserValue{}, base...)
+
+		for _, v := range override {
+			found := false
+
+			for _, existing := range merged {
+				if Equal(existing, v) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				merged = append(merged, v)
+			}
+		}
+
+		return merged
+	default:
+		return override
+	}
+}
+
+// DeletePath removes the value at path from values, objects along the way are copied rather than modified since
+// they may be shared with other values. it reports whether there was anything to delete
+func DeletePath(values *OrderedMap, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	if len(path) == 1 {
+		if !values.Has(path[0]) {
+			return false
+		}
+
+		values.Delete(path[0])
+		return true
+	}
+
+	child, ok := values.Get(path[0])
+	if !ok || child.GetType() != PARSER_VALUE_TYPE_OBJECT {
+		return false
+	}
+
+	childObj, _ := child.GetObject()
+	copied := childObj.Copy()
+
+	if !DeletePath(copied, path[1:]) {
+		return false
+	}
+
+	values.Set(path[0], &ParserValueObject{Value: copied})
+	return true
+}
+
+// SetPath sets the value at path in values, creating the objects along the way that don't exist yet. like DeletePath it copies
+// the objects it goes through instead of modifying them. when a segment before the last one holds something other than an object
+// nothing is set and the index of that segment is returned, otherwise -1
+func SetPath(values *OrderedMap, path []string, value ParserValue) int {
+	if len(path) == 1 {
+		values.Set(path[0], value)
+		return -1
+	}
+
+	childObj := NewOrderedMap()
+
+	if child, ok := values.Get(path[0]); ok {
+		obj, err := child.GetObject()
+		if err != nil {
+			return 0
+		}
+
+		childObj = obj.Copy()
+	}
+
+	blocked := SetPath(childObj, path[1:], value)
+	if blocked >= 0 {
+		return blocked + 1
+	}
+
+	values.Set(path[0], &ParserValueObject{Value: childObj})
+	return -1
+}
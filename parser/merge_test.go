@@ -0,0 +1,63 @@
+package parser
+
+import "testing"
+
+func TestMergeAssignNestedTypeMismatch(t *testing.T) {
+	for _, src := range []string{
+		"a.b = 1\na += {b = {c = 1}}",
+		"a = {b = {c = [1]}}\na += {b = {c = {d = 1}}}",
+		"$c = {x = {y = 1}}\n$c += {x = 2}",
+	} {
+		if _, err := parseSource(t, src); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestMergeAssignNested(t *testing.T) {
+	expectValues(t, `
+		a = {b = 1, l = [1], o = {x = 1}}
+		a += {b = "x", l = [2], o = {y = 2}}
+	`, map[string]string{
+		"a": `{ b = "x", l = [1, 2], o = { x = 1, y = 2 } }`,
+	})
+}
+
+func TestMergeListStrategies(t *testing.T) {
+	base, err := parseSource(t, `a = { l = [1, 2], o = { x = 1, y = [1] }, s = 1 }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	override, err := parseSource(t, `a = { l = [2, 3], o = { y = [2], z = 1 }, s = [1], n = null }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lists    string
+		expected string
+	}{
+		{"", `{ a = { l = [2, 3], o = { x = 1, y = [2], z = 1 }, s = [1], n = null } }`},
+		{MERGE_LISTS_REPLACE, `{ a = { l = [2, 3], o = { x = 1, y = [2], z = 1 }, s = [1], n = null } }`},
+		{MERGE_LISTS_APPEND, `{ a = { l = [1, 2, 2, 3], o = { x = 1, y = [1, 2], z = 1 }, s = [1], n = null } }`},
+		{MERGE_LISTS_UNIQUE_APPEND, `{ a = { l = [1, 2, 3], o = { x = 1, y = [1, 2], z = 1 }, s = [1], n = null } }`},
+	}
+
+	for _, tt := range tests {
+		merged := Merge(&ParserValueObject{Value: base}, &ParserValueObject{Value: override}, MergeOptions{Lists: tt.lists})
+
+		if got := merged.ValueToString(); got != tt.expected {
+			t.Errorf("%q: got %s, expected %s", tt.lists, got, tt.expected)
+		}
+	}
+
+	// neither side is modified
+	if got := (&ParserValueObject{Value: base}).ValueToString(); got != `{ a = { l = [1, 2], o = { x = 1, y = [1] }, s = 1 } }` {
+		t.Errorf("base was modified: %s", got)
+	}
+
+	if err := ValidateMergeOptions(MergeOptions{Lists: "prepend"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,87 @@
+package parser
+
+// OrderedMap maps keys to values and remembers the order in which keys were first defined
+type OrderedMap struct {
+	keys   []string
+	values map[string]ParserValue
+}
+
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		keys:   []string{},
+		values: make(map[string]ParserValue),
+	}
+}
+
+func (m *OrderedMap) Get(key string) (ParserValue, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *OrderedMap) Has(key string) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set adds or replaces a value, a key that is redefined keeps its original position
+func (m *OrderedMap) Set(key string, value ParserValue) {
+	if m.values == nil {
+		m.values = make(map[string]ParserValue)
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in definition order, the returned slice can be modified freely
+func (m *OrderedMap) Keys() []string {
+	if m == nil {
+		return []string{}
+	}
+
+	keys := make([]string, len(m.keys))
+	copy(keys, m.keys)
+
+	return keys
+}
+
+func (m *OrderedMap) Len() int {
+	if m == nil {
+		return 0
+	}
+
+	return len(m.keys)
+}
+
+// Copy returns a shallow copy of the map
+func (m *OrderedMap) Copy() *OrderedMap {
+	c := NewOrderedMap()
+
+	for _, k := range m.Keys() {
+		c.Set(k, m.values[k])
+	}
+
+	return c
+}
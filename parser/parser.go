@@ -1,13 +1,16 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"math/big"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/marzeq/mconf/diagnostics"
 	"github.com/marzeq/mconf/tokeniser"
 )
 
@@ -21,6 +24,10 @@ const (
 	PARSER_VALUE_TYPE_OBJECT = "OBJECT"
 )
 
+// FLOAT_PRECISION is the precision (in bits) floats are read with, the same as a float64 has so that values coming from go
+// (or from JSON, YAML and TOML) and the ones read from source compare equal
+const FLOAT_PRECISION = 53
+
 type ParserValue interface {
 	GetType() string
 
@@ -32,14 +39,21 @@ type ParserValue interface {
 	GetInt() (*big.Int, error)
 	GetBool() (bool, error)
 	GetList() ([]ParserValue, error)
-	GetObject() (map[string]ParserValue, error)
+	GetObject() (*OrderedMap, error)
 
 	IsNull() bool
 }
 
 type importCacheEntry struct {
-	values    map[string]ParserValue
-	constants map[string]ParserValue
+	values    *OrderedMap
+	constants *OrderedMap
+}
+
+// osFS reads files straight from the operating system, unlike os.DirFS it also accepts absolute paths
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
 }
 
 type Parser struct {
@@ -49,16 +63,38 @@ type Parser struct {
 	relativeDir string
 	currentFile string
 	importCache *map[string]importCacheEntry
+	fsys        fs.FS
+	env         *environment
+	errors      diagnostics.List
+	unset       [][]string
+	// imported is shared with every child parser, it lists the files imports read or tried to read
+	imported *[]string
+	// skipping is above 0 while parsing a value that isn't going to be used (the branch of a ternary that isn't taken,
+	// what comes after a `&&` or `||` that already has its answer, a default that isn't needed). errors about evaluating
+	// it aren't reported, operators and missing constants give null instead
+	skipping int
+}
+
+// errAlreadyReported is returned when parsing runs into a token the tokeniser already reported an error about
+var errAlreadyReported = errors.New("error already reported")
+
+// AddError records an error that parsing recovered from
+func (p *Parser) AddError(err error) {
+	if err == errAlreadyReported {
+		return
+	}
+
+	p.errors.Add(err)
 }
 
 func NewParser(tokens []tokeniser.Token, rootDir string, currentFile string, relativeDir string) Parser {
 	importCache := make(map[string]importCacheEntry)
 
-	fullFile := filepath.Join(rootDir, currentFile)
+	fullFile := path.Join(rootDir, currentFile)
 
 	importCache[fullFile] = importCacheEntry{
-		values:    make(map[string]ParserValue),
-		constants: make(map[string]ParserValue),
+		values:    NewOrderedMap(),
+		constants: NewOrderedMap(),
 	}
 
 	return Parser{
@@ -68,32 +104,102 @@ func NewParser(tokens []tokeniser.Token, rootDir string, currentFile string, rel
 		relativeDir: relativeDir,
 		currentFile: currentFile,
 		importCache: &importCache,
+		fsys:        osFS{},
+		env:         &environment{load: GetEnv},
+		imported:    &[]string{},
 	}
 }
 
+// SetFS makes imports read from fsys instead of the operating system, rootDir is then a path inside of fsys
+func (p *Parser) SetFS(fsys fs.FS) {
+	p.fsys = fsys
+}
+
 func (p *Parser) childParser(tokens []tokeniser.Token, currentFile string) Parser {
-	fullFile := filepath.Join(p.rootDir, currentFile)
+	fullFile := path.Join(p.rootDir, currentFile)
 
 	(*p.importCache)[fullFile] = importCacheEntry{
-		values:    make(map[string]ParserValue),
-		constants: make(map[string]ParserValue),
+		values:    NewOrderedMap(),
+		constants: NewOrderedMap(),
 	}
 
 	return Parser{
 		tokens:      tokens,
 		currIndex:   0,
 		rootDir:     p.rootDir,
+		relativeDir: p.relativeDir,
 		currentFile: currentFile,
 		importCache: p.importCache,
+		fsys:        p.fsys,
+		env:         p.env,
+		imported:    p.imported,
+	}
+}
+
+func (p *Parser) GetValues() *OrderedMap {
+	return (*p.importCache)[path.Join(p.rootDir, p.currentFile)].values
+}
+
+// environment is what constants fall back to, shared by a parser and its child parsers. it is only loaded the first
+// time something is looked up in it
+type environment struct {
+	load   func() map[string]ParserValue
+	values map[string]ParserValue
+}
+
+func (e *environment) get(name string) (ParserValue, bool) {
+	if e.values == nil {
+		e.values = e.load()
+	}
+
+	value, ok := e.values[name]
+	return value, ok
+}
+
+// SetEnv replaces the environment constants fall back to, by default it is a snapshot of the process environment taken
+// the first time a constant isn't found
+func (p *Parser) SetEnv(env map[string]string) {
+	p.SetEnvSource(func() map[string]string { return env })
+}
+
+// SetEnvSource is SetEnv for an environment that is only read when it's first needed, load is called at most once
+func (p *Parser) SetEnvSource(load func() map[string]string) {
+	p.env.load = func() map[string]ParserValue {
+		env := load()
+		values := make(map[string]ParserValue, len(env))
+
+		for k, v := range env {
+			values[k] = &ParserValueString{Value: v}
+		}
+
+		return values
 	}
+	p.env.values = nil
 }
 
-func (p *Parser) GetValues() map[string]ParserValue {
-	return (*p.importCache)[filepath.Join(p.rootDir, p.currentFile)].values
+// GetImportedFiles returns the full path of every file an import read or tried to read, including ones that failed
+func (p *Parser) GetImportedFiles() []string {
+	return *p.imported
 }
 
-func (p *Parser) GetConstants() map[string]ParserValue {
-	return (*p.importCache)[filepath.Join(p.rootDir, p.currentFile)].constants
+// GetUnset returns the paths removed with `@unset`, in the order they were removed in
+func (p *Parser) GetUnset() [][]string {
+	return p.unset
+}
+
+func (p *Parser) GetConstants() *OrderedMap {
+	return (*p.importCache)[path.Join(p.rootDir, p.currentFile)].constants
+}
+
+// ReadErrorReason strips the operation and file name from a file reading error, leaving only why it failed
+func ReadErrorReason(err error) string {
+	var pathErr *fs.PathError
+
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+
+	return err.Error()
 }
 
 func GetEnv() map[string]ParserValue {
@@ -108,13 +214,13 @@ func GetEnv() map[string]ParserValue {
 }
 
 func (p *Parser) GetConstant(name string) (ParserValue, bool) {
-	value, ok := p.GetConstants()[name]
+	value, ok := p.GetConstants().Get(name)
 
 	if ok {
 		return value, true
 	}
 
-	value, ok = GetEnv()[name]
+	value, ok = p.env.get(name)
 
 	if ok {
 		return value, true
@@ -151,7 +257,7 @@ func (p *Parser) GoBack() {
 	p.currIndex--
 }
 
-func (p *Parser) FormatErrorAtToken(message string, loc tokeniser.Location) error {
+func (p *Parser) Position(loc tokeniser.Location) diagnostics.Position {
 	var prettyFile string
 
 	if p.currentFile == "" {
@@ -160,23 +266,69 @@ func (p *Parser) FormatErrorAtToken(message string, loc tokeniser.Location) erro
 		prettyFile = path.Join(p.relativeDir, p.currentFile)
 	}
 
-	if loc.Line == 0 && loc.Col == 0 {
-		return fmt.Errorf(fmt.Sprintf("%s (EOF) - Parser error: %s", prettyFile, message))
+	return diagnostics.Position{File: prettyFile, Line: loc.Line, Col: loc.Col}
+}
+
+func (p *Parser) diagnostic(code string, message string, loc tokeniser.Location) diagnostics.Diagnostic {
+	return diagnostics.Diagnostic{
+		Position: p.Position(loc),
+		Stage:    diagnostics.STAGE_PARSER,
+		Code:     code,
+		Message:  message,
 	}
+}
+
+func (p *Parser) FormatErrorAtToken(code string, message string, loc tokeniser.Location) error {
+	return &diagnostics.SyntaxError{Diagnostic: p.diagnostic(code, message, loc)}
+}
 
-	return fmt.Errorf(fmt.Sprintf("%s:%d:%d - Parser error: %s", prettyFile, loc.Line, loc.Col, message))
+func (p *Parser) ConstantNotFoundError(name string, message string, loc tokeniser.Location) error {
+	return &diagnostics.ConstantNotFoundError{Diagnostic: p.diagnostic(diagnostics.CODE_CONSTANT_NOT_FOUND, message, loc), Name: name}
+}
+
+func (p *Parser) TypeErrorAtToken(expected string, actual string, message string, loc tokeniser.Location) error {
+	return &diagnostics.TypeError{Diagnostic: p.diagnostic(diagnostics.CODE_TYPE_MISMATCH, message, loc), Expected: expected, Actual: actual}
+}
+
+func (p *Parser) EvaluationErrorAtToken(code string, message string, loc tokeniser.Location) error {
+	return &diagnostics.EvaluationError{Diagnostic: p.diagnostic(code, message, loc)}
+}
+
+func (p *Parser) ImportErrorAtToken(code string, importPath string, message string, err error, loc tokeniser.Location) error {
+	return &diagnostics.ImportError{Diagnostic: p.diagnostic(code, message, loc), Path: importPath, Err: err}
 }
 
 func (p *Parser) ParseDeepKey() ([]string, error) {
+	key, _, err := p.ParseDeepKeyTokens()
+	return key, err
+}
+
+// ParseDeepKeyTokens is ParseDeepKey that also returns the token every segment of the key came from
+func (p *Parser) ParseDeepKeyTokens() ([]string, []tokeniser.Token, error) {
 	key := make([]string, 0)
+	tokens := make([]tokeniser.Token, 0)
 
 	for {
 		token := p.Consume()
 
-		if token.Type == tokeniser.TOKEN_TYPE_KEY || token.Type == tokeniser.TOKEN_TYPE_STRING {
+		switch token.Type {
+		case tokeniser.TOKEN_TYPE_KEY:
 			key = append(key, token.Value)
+		case tokeniser.TOKEN_TYPE_STRING:
+			evkey, err := p.EvaluateStringValue(token)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			key = append(key, evkey)
+		case tokeniser.TOKEN_TYPE_INVALID:
+			return nil, nil, errAlreadyReported
+		default:
+			return nil, nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s, expected a key", token.Type), token.Start)
 		}
 
+		tokens = append(tokens, token)
+
 		next := p.Peek()
 
 		if next.Type == tokeniser.TOKEN_TYPE_DOT {
@@ -186,7 +338,134 @@ func (p *Parser) ParseDeepKey() ([]string, error) {
 		}
 	}
 
-	return key, nil
+	return key, tokens, nil
+}
+
+// IsAssignment reports whether a token is `=` (or `:`) or `+=`
+func IsAssignment(token tokeniser.Token) bool {
+	return token.Type == tokeniser.TOKEN_TYPE_ASSIGN || token.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN
+}
+
+// MergeAssignment returns what `+=` sets a key that holds existing (nil when it isn't set) to: objects are merged
+// recursively and lists concatenated, anything else can't be merged. inside of the merged objects other values
+// replace each other, as long as an object or a list isn't replaced by something else or the other way around
+func (p *Parser) MergeAssignment(name string, existing ParserValue, value ParserValue, assign tokeniser.Token) (ParserValue, error) {
+	if existing == nil {
+		return value, nil
+	}
+
+	if existing.GetType() != value.GetType() || !isContainer(value) {
+		return nil, p.mergeTypeError(name, existing, value, assign)
+	}
+
+	if err := p.checkMergeTypes(name, existing, value, assign); err != nil {
+		return nil, err
+	}
+
+	return Merge(existing, value, MergeOptions{Lists: MERGE_LISTS_APPEND}), nil
+}
+
+func isContainer(v ParserValue) bool {
+	return v.GetType() == PARSER_VALUE_TYPE_OBJECT || v.GetType() == PARSER_VALUE_TYPE_LIST
+}
+
+// checkMergeTypes returns an error for the first key both objects have where only one of them holds an object or a list,
+// or where they hold different ones
+func (p *Parser) checkMergeTypes(name string, existing ParserValue, value ParserValue, assign tokeniser.Token) error {
+	existingObj, err := existing.GetObject()
+	if err != nil {
+		return nil
+	}
+
+	valueObj, _ := value.GetObject()
+
+	for _, k := range valueObj.Keys() {
+		before, ok := existingObj.Get(k)
+		if !ok {
+			continue
+		}
+
+		after, _ := valueObj.Get(k)
+		path := AppendPathKey(name, k)
+
+		if !isContainer(before) && !isContainer(after) {
+			continue
+		}
+
+		if before.GetType() != after.GetType() {
+			return p.mergeTypeError(path, before, after, assign)
+		}
+
+		if err := p.checkMergeTypes(path, before, after, assign); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) mergeTypeError(name string, existing ParserValue, value ParserValue, assign tokeniser.Token) error {
+	message := fmt.Sprintf("`+=` only merges objects into objects and lists into lists, `%s` is a value of type %s and the merged value is of type %s", name, strings.ToLower(existing.GetType()), strings.ToLower(value.GetType()))
+	return p.TypeErrorAtToken(existing.GetType(), value.GetType(), message, assign.Start)
+}
+
+// lookupKeys returns the value at key in object, only going through objects
+func lookupKeys(object *OrderedMap, key []string) ParserValue {
+	var value ParserValue = &ParserValueObject{Value: object}
+
+	for _, k := range key {
+		obj, err := value.GetObject()
+		if err != nil {
+			return nil
+		}
+
+		next, ok := obj.Get(k)
+		if !ok {
+			return nil
+		}
+
+		value = next
+	}
+
+	return value
+}
+
+// ParseKeyAssignment parses a `key = value` or `key += value` assignment into object. a dotted key like `a.b.c = value`
+// sets the value inside of nested objects, creating the ones that don't exist yet
+func (p *Parser) ParseKeyAssignment(object *OrderedMap) error {
+	key, keyTokens, err := p.ParseDeepKeyTokens()
+	if err != nil {
+		return err
+	}
+
+	assign := p.Consume()
+
+	if !IsAssignment(assign) {
+		return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected assignment operator `=` or `+=`", assign.Start)
+	}
+
+	value, err := p.ParseValue()
+	if err != nil {
+		return err
+	}
+
+	if assign.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+		value, err = p.MergeAssignment(FormatPath(key), lookupKeys(object, key), value, assign)
+		if err != nil {
+			return err
+		}
+	}
+
+	blocked := SetPath(object, key, value)
+
+	if blocked >= 0 {
+		existing, _ := LookupPath(&ParserValueObject{Value: object}, key[:blocked+1])
+		message := fmt.Sprintf("Cannot set `%s`, `%s` is already set to a value of type %s, not an object", FormatPath(key), FormatPath(key[:blocked+1]), strings.ToLower(existing.GetType()))
+
+		return p.TypeErrorAtToken(PARSER_VALUE_TYPE_OBJECT, existing.GetType(), message, keyTokens[blocked].Start)
+	}
+
+	return nil
 }
 
 func (p *Parser) EvaluateStringValue(token tokeniser.Token) (string, error) {
@@ -197,8 +476,12 @@ func (p *Parser) EvaluateStringValue(token tokeniser.Token) (string, error) {
 		if i < len(token.StringSubs) {
 			constantName := token.StringSubs[i]
 			constantValue, ok := p.GetConstant(constantName)
+			if !ok && p.skipping > 0 {
+				continue
+			}
+
 			if !ok {
-				return "", p.FormatErrorAtToken(fmt.Sprintf("Constant in string substitution `%s` not found", constantName), token.Start)
+				return "", p.ConstantNotFoundError(constantName, fmt.Sprintf("Constant in string substitution `%s` not found", constantName), token.Start)
 			}
 
 			if constantValue.GetType() != PARSER_VALUE_TYPE_STRING {
@@ -217,8 +500,14 @@ func (p *Parser) EvaluateStringValue(token tokeniser.Token) (string, error) {
 	return sb, nil
 }
 
+// ParseTernaryExpression parses the `a | b` after `condition ~`, only the branch the condition picks is evaluated.
+// a condition that isn't a bool (only possible while skipping) picks neither
 func (p *Parser) ParseTernaryExpression(condition ParserValue) (ParserValue, error) {
-	first, err := p.ParseValue()
+	valueBool, err := condition.GetBool()
+	useFirst := err == nil && valueBool
+	useSecond := err == nil && !valueBool
+
+	first, err := p.parseMaybeSkipped(!useFirst, p.ParseValue)
 	if err != nil {
 		return nil, err
 	}
@@ -226,26 +515,38 @@ func (p *Parser) ParseTernaryExpression(condition ParserValue) (ParserValue, err
 	pipe := p.Consume()
 
 	if pipe.Type != tokeniser.TOKEN_TYPE_PIPE {
-		return nil, p.FormatErrorAtToken("Expected pipe `|`", pipe.Start)
-	}
-
-	second, err := p.ParseValue()
-	if err != nil {
-		return nil, err
+		return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected pipe `|`", pipe.Start)
 	}
 
-	valueBool, err := condition.GetBool()
+	second, err := p.parseMaybeSkipped(!useSecond, p.ParseValue)
 	if err != nil {
 		return nil, err
 	}
 
-	if valueBool {
+	if useFirst {
 		return first, nil
 	} else {
 		return second, nil
 	}
 }
 
+// parseMaybeSkipped calls parse, skipping what it parses if skip is set, see Parser.skipping
+func (p *Parser) parseMaybeSkipped(skip bool, parse func() (ParserValue, error)) (ParserValue, error) {
+	if !skip {
+		return parse()
+	}
+
+	p.skipping++
+	defer func() { p.skipping-- }()
+
+	_, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParserValueNull{true}, nil
+}
+
 func (p *Parser) ParseConstantWithBackup() (ParserValue, error) {
 	token := p.Consume()
 
@@ -265,7 +566,7 @@ func (p *Parser) ParseConstantWithBackup() (ParserValue, error) {
 						continue
 					} else {
 						p.GoBack()
-						_, err := p.ParseValue()
+						_, err := p.parseMaybeSkipped(true, p.ParseOperand)
 						if err != nil {
 							return nil, err
 						}
@@ -276,17 +577,6 @@ func (p *Parser) ParseConstantWithBackup() (ParserValue, error) {
 				}
 			}
 
-			possibleTilde := p.Peek()
-
-			if possibleTilde.Type == tokeniser.TOKEN_TYPE_TILDE {
-				if value.GetType() != PARSER_VALUE_TYPE_BOOL {
-					return nil, p.FormatErrorAtToken("Ternary operator `~` can only be used with boolean constants", possibleTilde.Start)
-				}
-
-				p.Increment()
-
-				return p.ParseTernaryExpression(value)
-			}
 			return value, nil
 		}
 
@@ -297,14 +587,39 @@ func (p *Parser) ParseConstantWithBackup() (ParserValue, error) {
 			return p.ParseConstantWithBackup()
 		}
 
-		return nil, p.FormatErrorAtToken(fmt.Sprintf("Constant `%s` not found", token.Value), token.Start)
+		if p.skipping > 0 {
+			return &ParserValueNull{true}, nil
+		}
+
+		return nil, p.ConstantNotFoundError(token.Value, fmt.Sprintf("Constant `%s` not found", token.Value), token.Start)
 	} else {
 		p.GoBack()
-		return p.ParseValue()
+		return p.ParseOperand()
 	}
 }
 
+// ParseValue parses a whole value: operands joined by operators, optionally followed by a ternary
 func (p *Parser) ParseValue() (ParserValue, error) {
+	value, err := p.ParseBinaryExpression(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Peek().Type == tokeniser.TOKEN_TYPE_TILDE {
+		tilde := p.Consume()
+
+		if value.GetType() != PARSER_VALUE_TYPE_BOOL && p.skipping == 0 {
+			return nil, p.TypeErrorAtToken(PARSER_VALUE_TYPE_BOOL, value.GetType(), "Ternary operator `~` can only be used with boolean conditions", tilde.Start)
+		}
+
+		return p.ParseTernaryExpression(value)
+	}
+
+	return value, nil
+}
+
+// ParseOperand parses a single literal, constant (with its defaults), list, object or parenthesised value
+func (p *Parser) ParseOperand() (ParserValue, error) {
 	token := p.Consume()
 
 	switch token.Type {
@@ -316,17 +631,17 @@ func (p *Parser) ParseValue() (ParserValue, error) {
 
 		return &ParserValueString{Value: sb}, nil
 	case tokeniser.TOKEN_TYPE_NUMBER_DECIMAL:
-		if strings.Contains(token.Value, ".") {
-			bigFl, _, err := big.ParseFloat(token.Value, 10, 0, big.ToNearestEven)
+		if strings.ContainsAny(token.Value, ".eE") {
+			bigFl, _, err := big.ParseFloat(token.Value, 10, FLOAT_PRECISION, big.ToNearestEven)
 			if err != nil {
-				return nil, p.FormatErrorAtToken(fmt.Sprintf("Failed to convert `%s` to float", token.Value), token.Start)
+				return nil, p.FormatErrorAtToken(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Failed to convert `%s` to float", token.Value), token.Start)
 			}
 
 			return &ParserValueFloat{Value: bigFl}, nil
 		} else {
 			intVal, success := new(big.Int).SetString(token.Value, 10)
 			if !success {
-				return nil, p.FormatErrorAtToken(fmt.Sprintf("Failed to convert `%s` to decimal int", token.Value), token.Start)
+				return nil, p.FormatErrorAtToken(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Failed to convert `%s` to decimal int", token.Value), token.Start)
 			}
 
 			return &ParserValueInt{Value: intVal}, nil
@@ -334,14 +649,14 @@ func (p *Parser) ParseValue() (ParserValue, error) {
 	case tokeniser.TOKEN_TYPE_NUMBER_HEX:
 		intVal, success := new(big.Int).SetString(token.Value, 16)
 		if !success {
-			return nil, p.FormatErrorAtToken(fmt.Sprintf("Failed to convert `%s` to hex int", token.Value), token.Start)
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Failed to convert `%s` to hex int", token.Value), token.Start)
 		}
 
 		return &ParserValueInt{Value: intVal}, nil
 	case tokeniser.TOKEN_TYPE_NUMBER_BINARY:
 		intVal, success := new(big.Int).SetString(token.Value, 2)
 		if !success {
-			return nil, p.FormatErrorAtToken(fmt.Sprintf("Failed to convert `%s` to binary int", token.Value), token.Start)
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Failed to convert `%s` to binary int", token.Value), token.Start)
 		}
 
 		return &ParserValueInt{Value: intVal}, nil
@@ -353,15 +668,7 @@ func (p *Parser) ParseValue() (ParserValue, error) {
 		} else if token.Value == "false" {
 			converted = false
 		} else {
-			return nil, p.FormatErrorAtToken(fmt.Sprintf("Failed to convert `%s` to bool", token.Value), token.Start)
-		}
-
-		peeked := p.Peek()
-
-		if peeked.Type == tokeniser.TOKEN_TYPE_TILDE {
-			p.Increment()
-
-			return p.ParseTernaryExpression(&ParserValueBool{Value: converted})
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Failed to convert `%s` to bool", token.Value), token.Start)
 		}
 
 		return &ParserValueBool{Value: converted}, nil
@@ -389,11 +696,30 @@ func (p *Parser) ParseValue() (ParserValue, error) {
 		}
 
 		return &ParserValueObject{Value: parsedObj}, nil
+	case tokeniser.TOKEN_TYPE_OPEN_PAREN:
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		closeParen := p.Consume()
+
+		if closeParen.Type != tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected closing parenthesis `)`", closeParen.Start)
+		}
+
+		return value, nil
+	case tokeniser.TOKEN_TYPE_KEY:
+		if p.Peek().Type != tokeniser.TOKEN_TYPE_OPEN_PAREN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
+		}
+
+		return p.ParseCall(token)
+	case tokeniser.TOKEN_TYPE_INVALID:
+		return nil, errAlreadyReported
 	default:
-		return nil, p.FormatErrorAtToken(fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
+		return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
 	}
-
-	return nil, fmt.Errorf("Unreachable code reached, please report this as a bug")
 }
 
 func (p *Parser) ParseList() ([]ParserValue, error) {
@@ -422,6 +748,14 @@ func (p *Parser) ParseList() ([]ParserValue, error) {
 			fallthrough
 		case tokeniser.TOKEN_TYPE_OPEN_OBJ:
 			fallthrough
+		case tokeniser.TOKEN_TYPE_OPEN_PAREN:
+			fallthrough
+		case tokeniser.TOKEN_TYPE_MINUS:
+			fallthrough
+		case tokeniser.TOKEN_TYPE_NOT:
+			fallthrough
+		case tokeniser.TOKEN_TYPE_KEY:
+			fallthrough
 		case tokeniser.TOKEN_TYPE_CONSTANT:
 			{
 				value, err := p.ParseValue()
@@ -434,114 +768,189 @@ func (p *Parser) ParseList() ([]ParserValue, error) {
 				if comma_or_close.Type == tokeniser.TOKEN_TYPE_COMMA {
 					p.Increment()
 				} else if comma_or_close.Type != tokeniser.TOKEN_TYPE_CLOSE_LIST {
-					return nil, p.FormatErrorAtToken("Expected comma or closing bracket", comma_or_close.Start)
+					return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing bracket", comma_or_close.Start)
 				}
 
 				list = append(list, value)
 			}
+		case tokeniser.TOKEN_TYPE_INVALID:
+			return nil, errAlreadyReported
 		default:
 			{
-				return nil, p.FormatErrorAtToken(fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
+				return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
 			}
 		}
 	}
-
-	return list, nil
 }
 
-func (p *Parser) ParseObject() (map[string]ParserValue, error) {
-	object := make(map[string]ParserValue)
+func (p *Parser) ParseObject() (*OrderedMap, error) {
+	object := NewOrderedMap()
+
+	err := p.ParseObjectMembers(object)
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
 
+// ParseObjectMembers parses the members of an object up to and including its closing bracket into object
+func (p *Parser) ParseObjectMembers(object *OrderedMap) error {
 	for {
-		token := p.Consume()
+		memberStart := p.currIndex
+		token := p.Peek()
 
-		switch token.Type {
-		case tokeniser.TOKEN_TYPE_CLOSE_OBJ:
-			return object, nil
-		case tokeniser.TOKEN_TYPE_KEY:
-			fallthrough
-		case tokeniser.TOKEN_TYPE_STRING:
-			{
-				var key string
+		if token.Type == tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+			p.Increment()
+			return nil
+		}
 
-				if token.Type == tokeniser.TOKEN_TYPE_KEY {
-					key = token.Value
-				} else {
-					evkey, err := p.EvaluateStringValue(token)
-					if err != nil {
-						return nil, err
-					}
+		if token.Type == tokeniser.TOKEN_TYPE_EOF {
+			return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Unexpected end of file, expected closing bracket `}`", token.Start)
+		}
 
-					key = evkey
-				}
+		err := p.ParseObjectMember(object)
+		if err != nil {
+			// one broken member doesn't make the rest of the object unreadable, so report it and move on to the next one
+			p.AddError(err)
+			p.Synchronize(memberStart, false)
+		}
+	}
+}
 
-				assign := p.Consume()
+func (p *Parser) ParseObjectMember(object *OrderedMap) error {
+	token := p.Consume()
 
-				if assign.Type != tokeniser.TOKEN_TYPE_ASSIGN {
-					return nil, p.FormatErrorAtToken("Expected assignment operator `=`", assign.Start)
-				}
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_INVALID:
+		return errAlreadyReported
+	case tokeniser.TOKEN_TYPE_KEY:
+		fallthrough
+	case tokeniser.TOKEN_TYPE_STRING:
+		{
+			p.GoBack()
 
-				value, err := p.ParseValue()
-				if err != nil {
-					return nil, err
-				}
+			err := p.ParseKeyAssignment(object)
+			if err != nil {
+				return err
+			}
 
-				object[key] = value
+			optional_comma := p.Peek()
 
-				optional_comma := p.Peek()
+			if optional_comma.Type == tokeniser.TOKEN_TYPE_COMMA {
+				p.Increment()
+			}
+		}
+	default:
+		{
+			return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
+		}
+	}
 
-				if optional_comma.Type == tokeniser.TOKEN_TYPE_COMMA {
-					p.Increment()
-				}
+	return nil
+}
+
+// AtAssignment reports whether the next tokens start a `key = value`, `a.b = value` or `$constant = value` assignment (or a `+=` one)
+func (p *Parser) AtAssignment() bool {
+	switch p.Peek().Type {
+	case tokeniser.TOKEN_TYPE_CONSTANT:
+		return IsAssignment(p.PeekAhead(1))
+	case tokeniser.TOKEN_TYPE_KEY, tokeniser.TOKEN_TYPE_STRING:
+		// the key may be dotted, `a.b.c = value`
+		ahead := 1
+
+		for p.PeekAhead(ahead).Type == tokeniser.TOKEN_TYPE_DOT {
+			switch p.PeekAhead(ahead + 1).Type {
+			case tokeniser.TOKEN_TYPE_KEY, tokeniser.TOKEN_TYPE_STRING:
+				ahead += 2
+			default:
+				return false
 			}
+		}
+
+		return IsAssignment(p.PeekAhead(ahead))
+	default:
+		return false
+	}
+}
+
+// Synchronize skips the tokens left over from a statement (or object member) that failed to parse,
+// stopping at the next assignment, directive or, inside of an object, its closing bracket
+func (p *Parser) Synchronize(start int, topLevel bool) {
+	// the token that caused the error may well be the start of the next statement, so go back to it, but always make progress
+	p.currIndex = max(p.currIndex-1, start+1)
+
+	depth := 0
+
+	for {
+		token := p.Peek()
+
+		switch token.Type {
+		case tokeniser.TOKEN_TYPE_EOF:
+			return
+		case tokeniser.TOKEN_TYPE_DIRECTIVE:
+			if topLevel && depth <= 0 {
+				return
+			}
+		case tokeniser.TOKEN_TYPE_OPEN_OBJ, tokeniser.TOKEN_TYPE_OPEN_LIST:
+			depth++
+		case tokeniser.TOKEN_TYPE_CLOSE_OBJ:
+			if !topLevel && depth <= 0 {
+				return
+			}
+
+			depth--
+		case tokeniser.TOKEN_TYPE_CLOSE_LIST:
+			depth--
 		default:
-			{
-				return nil, p.FormatErrorAtToken(fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
+			if depth <= 0 && p.AtAssignment() {
+				return
 			}
 		}
-	}
 
-	return object, nil
+		p.Increment()
+	}
 }
 
 func (p *Parser) SmartlySetValuesAndConstants(importEverything bool, importPaths [][]string, importConstants []string, ic importCacheEntry, errorLoc tokeniser.Location, importPath string) error {
 	if importEverything {
-		for k, v := range ic.values {
-			p.GetValues()[k] = v
+		for _, k := range ic.values.Keys() {
+			v, _ := ic.values.Get(k)
+			p.GetValues().Set(k, v)
 		}
 
-		for k, v := range ic.constants {
-			p.GetConstants()[k] = v
+		for _, k := range ic.constants.Keys() {
+			v, _ := ic.constants.Get(k)
+			p.GetConstants().Set(k, v)
 		}
 	} else {
 		for _, path := range importPaths {
 			current := ic.values
 
 			for i, key := range path {
-				indexedVal, ok := current[key]
+				indexedVal, ok := current.Get(key)
 				if !ok {
 					joinedPath := strings.Join(path[:i+1], ".")
-					return p.FormatErrorAtToken(fmt.Sprintf("Path `%s` not found in imported file %s", joinedPath, importPath), errorLoc)
+					return p.ImportErrorAtToken(diagnostics.CODE_IMPORT_PATH_NOT_FOUND, importPath, fmt.Sprintf("Path `%s` not found in imported file %s", joinedPath, importPath), nil, errorLoc)
 				}
 
 				if i == len(path)-1 {
-					p.GetValues()[key] = indexedVal
+					p.GetValues().Set(key, indexedVal)
 					break
 				}
 
 				got, err := indexedVal.GetObject()
 				if err != nil {
-					return p.FormatErrorAtToken(fmt.Sprintf("Path `%s` in imported file %s is not an object", strings.Join(path[:i+1], "."), importPath), errorLoc)
+					return p.ImportErrorAtToken(diagnostics.CODE_IMPORT_NOT_OBJECT, importPath, fmt.Sprintf("Path `%s` in imported file %s is not an object", strings.Join(path[:i+1], "."), importPath), err, errorLoc)
 				}
 				current = got
 			}
 		}
 
-		for k, v := range ic.constants {
-			for _, constant := range importConstants {
-				if k == constant {
-					p.GetConstants()[k] = v
-				}
+		for _, constant := range importConstants {
+			v, ok := ic.constants.Get(constant)
+			if ok {
+				p.GetConstants().Set(constant, v)
 			}
 		}
 	}
@@ -549,187 +958,196 @@ func (p *Parser) SmartlySetValuesAndConstants(importEverything bool, importPaths
 	return nil
 }
 
-func (p *Parser) Parse() (map[string]ParserValue, error) {
-	for {
-		token := p.Consume()
-
-		switch token.Type {
-		case tokeniser.TOKEN_TYPE_EOF:
-			return p.GetValues(), nil
-		case tokeniser.TOKEN_TYPE_KEY:
-			fallthrough
-		case tokeniser.TOKEN_TYPE_STRING:
-			{
-				var key string
-
-				if token.Type == tokeniser.TOKEN_TYPE_KEY {
-					key = token.Value
-				} else {
-					evkey, err := p.EvaluateStringValue(token)
-					if err != nil {
-						return nil, err
-					}
-
-					key = evkey
-				}
+// ParseStatement parses a single top-level assignment, block or directive
+func (p *Parser) ParseStatement() error {
+	token := p.Consume()
 
-				assign := p.Consume()
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_INVALID:
+		return errAlreadyReported
+	case tokeniser.TOKEN_TYPE_KEY:
+		fallthrough
+	case tokeniser.TOKEN_TYPE_STRING:
+		{
+			p.GoBack()
 
-				if assign.Type != tokeniser.TOKEN_TYPE_ASSIGN {
-					return nil, p.FormatErrorAtToken("Expected assignment operator `=`", assign.Start)
-				}
+			err := p.ParseKeyAssignment(p.GetValues())
+			if err != nil {
+				return err
+			}
+		}
+	case tokeniser.TOKEN_TYPE_CONSTANT:
+		{
+			key := token.Value
 
-				value, err := p.ParseValue()
-				if err != nil {
-					return nil, err
-				}
+			assign := p.Consume()
 
-				p.GetValues()[key] = value
+			if !IsAssignment(assign) {
+				return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected assignment operator `=` or `+=`", assign.Start)
 			}
-		case tokeniser.TOKEN_TYPE_CONSTANT:
-			{
-				key := token.Value
 
-				assign := p.Consume()
+			value, err := p.ParseValue()
+			if err != nil {
+				return err
+			}
 
-				if assign.Type != tokeniser.TOKEN_TYPE_ASSIGN {
-					return nil, p.FormatErrorAtToken("Expected assignment operator `=`", assign.Start)
-				}
+			if assign.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+				existing, _ := p.GetConstants().Get(key)
 
-				value, err := p.ParseValue()
+				value, err = p.MergeAssignment("$"+key, existing, value, assign)
 				if err != nil {
-					return nil, err
+					return err
 				}
-
-				p.GetConstants()[key] = value
 			}
-		case tokeniser.TOKEN_TYPE_OPEN_OBJ:
-			{
-				object, err := p.ParseObject()
-				if err != nil {
-					return nil, err
-				}
 
-				for k, v := range object {
-					p.GetValues()[k] = v
-				}
+			p.GetConstants().Set(key, value)
+		}
+	case tokeniser.TOKEN_TYPE_OPEN_OBJ:
+		{
+			// the members of a top level block are set right at the top level, so that dotted keys and `+=` in it
+			// extend what is already there
+			err := p.ParseObjectMembers(p.GetValues())
+			if err != nil {
+				return err
 			}
-		case tokeniser.TOKEN_TYPE_DIRECTIVE:
-			{
-				switch token.Value {
-				case "import":
-					{
-						nextUnknown := p.Peek()
-
-						importPaths := [][]string{}
-						importConstants := []string{}
-						importEverything := true
-
-						if nextUnknown.Type == tokeniser.TOKEN_TYPE_OPEN_OBJ {
-							p.Increment()
-							importEverything = false
-							for {
-								tok := p.Peek()
-
-								if tok.Type == tokeniser.TOKEN_TYPE_CLOSE_OBJ {
-									p.Increment()
-									break
-								}
-
-								if tok.Type == tokeniser.TOKEN_TYPE_CONSTANT {
-									p.Increment()
-									importConstants = append(importConstants, tok.Value)
-								} else {
-									key, err := p.ParseDeepKey()
-									if err != nil {
-										return nil, err
-									}
+		}
+	case tokeniser.TOKEN_TYPE_DIRECTIVE:
+		{
+			switch token.Value {
+			case "import":
+				{
+					nextUnknown := p.Peek()
+
+					importPaths := [][]string{}
+					importConstants := []string{}
+					importEverything := true
+
+					if nextUnknown.Type == tokeniser.TOKEN_TYPE_OPEN_OBJ {
+						p.Increment()
+						importEverything = false
+						for {
+							tok := p.Peek()
+
+							if tok.Type == tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+								p.Increment()
+								break
+							}
 
-									importPaths = append(importPaths, key)
+							if tok.Type == tokeniser.TOKEN_TYPE_CONSTANT {
+								p.Increment()
+								importConstants = append(importConstants, tok.Value)
+							} else {
+								key, err := p.ParseDeepKey()
+								if err != nil {
+									return err
 								}
 
-								comma_or_close := p.Peek()
+								importPaths = append(importPaths, key)
+							}
 
-								if comma_or_close.Type == tokeniser.TOKEN_TYPE_COMMA {
-									p.Increment()
-								} else if comma_or_close.Type != tokeniser.TOKEN_TYPE_CLOSE_OBJ {
-									return nil, p.FormatErrorAtToken("Expected comma or closing bracket", comma_or_close.Start)
-								}
+							comma_or_close := p.Peek()
+
+							if comma_or_close.Type == tokeniser.TOKEN_TYPE_COMMA {
+								p.Increment()
+							} else if comma_or_close.Type != tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+								return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing bracket", comma_or_close.Start)
 							}
 						}
+					}
 
-						ipToken := p.Consume()
+					ipToken := p.Consume()
 
-						if ipToken.Type != tokeniser.TOKEN_TYPE_STRING {
-							return nil, p.FormatErrorAtToken("Expected string path to import", ipToken.Start)
-						}
+					if ipToken.Type != tokeniser.TOKEN_TYPE_STRING {
+						return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected string path to import", ipToken.Start)
+					}
 
-						importPath, ipPathErr := p.EvaluateStringValue(ipToken)
+					importPath, ipPathErr := p.EvaluateStringValue(ipToken)
 
-						if ipPathErr != nil {
-							return nil, ipPathErr
-						}
+					if ipPathErr != nil {
+						return ipPathErr
+					}
 
-						if importPath == p.currentFile {
-							return nil, p.FormatErrorAtToken("Cannot import the same file", ipToken.Start)
-						}
+					if importPath == p.currentFile {
+						return p.ImportErrorAtToken(diagnostics.CODE_IMPORT_SELF, importPath, "Cannot import the same file", nil, ipToken.Start)
+					}
 
-						fullFilePath := filepath.Join(p.rootDir, importPath)
-						relative, err := filepath.Rel(p.rootDir, fullFilePath)
+					fullFilePath := path.Join(p.rootDir, importPath)
+					relative := path.Clean(importPath)
 
-						ic, icOk := (*p.importCache)[fullFilePath]
+					ic, icOk := (*p.importCache)[fullFilePath]
 
-						if icOk {
-							err := p.SmartlySetValuesAndConstants(importEverything, importPaths, importConstants, ic, ipToken.Start, importPath)
-							if err != nil {
-								return nil, err
-							}
-							continue
-						}
+					if icOk {
+						return p.SmartlySetValuesAndConstants(importEverything, importPaths, importConstants, ic, ipToken.Start, importPath)
+					}
 
-						f, err := os.ReadFile(fullFilePath)
-						if err != nil {
-							err = p.FormatErrorAtToken(fmt.Sprintf("error reading file %s,%s", relative, strings.Split(err.Error(), ":")[1]), ipToken.Start)
-							return nil, err
-						}
+					*p.imported = append(*p.imported, fullFilePath)
 
-						s := string(f)
+					f, err := fs.ReadFile(p.fsys, fullFilePath)
+					if err != nil {
+						err = p.ImportErrorAtToken(diagnostics.CODE_IMPORT_READ_FAILED, importPath, fmt.Sprintf("error reading file %s, %s", relative, ReadErrorReason(err)), err, ipToken.Start)
+						return err
+					}
 
-						t := tokeniser.NewTokeniser(s, relative, p.relativeDir)
-						tokens, errTokenise := t.Tokenise()
-						if errTokenise != nil {
-							return nil, errTokenise
-						}
+					s := string(f)
 
-						p2 := p.childParser(tokens, relative)
-						_, errParse := p2.Parse()
-						if errParse != nil {
-							return nil, errParse
-						}
+					// errors in the imported file are reported alongside the ones in this file, whatever was parsed successfully is still imported
+					t := tokeniser.NewTokeniser(s, relative, p.relativeDir)
+					tokens, errTokenise := t.Tokenise()
+					p.AddError(errTokenise)
 
-						ic, icOk = (*p.importCache)[fullFilePath]
+					p2 := p.childParser(tokens, relative)
+					_, errParse := p2.Parse()
+					p.AddError(errParse)
 
-						if !icOk {
-							return nil, fmt.Errorf("Unreachable code reached, please report this as a bug")
-						}
+					ic, icOk = (*p.importCache)[fullFilePath]
 
-						err = p.SmartlySetValuesAndConstants(importEverything, importPaths, importConstants, ic, ipToken.Start, importPath)
-						if err != nil {
-							return nil, err
-						}
+					if !icOk {
+						return p.FormatErrorAtToken(diagnostics.CODE_INTERNAL, "Unreachable code reached, please report this as a bug", ipToken.Start)
 					}
-				default:
-					{
-						return nil, p.FormatErrorAtToken(fmt.Sprintf("Unknown directive `%s`", token.Value), token.Start)
+
+					err = p.SmartlySetValuesAndConstants(importEverything, importPaths, importConstants, ic, ipToken.Start, importPath)
+					if err != nil {
+						return err
 					}
 				}
+			case "unset":
+				{
+					key, err := p.ParseDeepKey()
+					if err != nil {
+						return err
+					}
+
+					DeletePath(p.GetValues(), key)
+					p.unset = append(p.unset, key)
+				}
+			default:
+				{
+					return p.FormatErrorAtToken(diagnostics.CODE_UNKNOWN_DIRECTIVE, fmt.Sprintf("Unknown directive `%s`", token.Value), token.Start)
+				}
 			}
-		default:
-			{
-				return nil, p.FormatErrorAtToken(fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
-			}
+		}
+	default:
+		{
+			return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token.Start)
 		}
 	}
 
-	return p.GetValues(), nil
+	return nil
+}
+
+// Parse parses every statement, errors don't stop it, they are collected and returned together as a diagnostics.List
+func (p *Parser) Parse() (*OrderedMap, error) {
+	for {
+		statementStart := p.currIndex
+
+		if p.Peek().Type == tokeniser.TOKEN_TYPE_EOF {
+			return p.GetValues(), p.errors.Err()
+		}
+
+		err := p.ParseStatement()
+		if err != nil {
+			p.AddError(err)
+			p.Synchronize(statementStart, true)
+		}
+	}
 }
@@ -22,7 +22,7 @@ func (v *ParserValueBool) ValueToString(indentAndDepth ...int) string {
 }
 
 func (v *ParserValueBool) ToJSONString() string {
-	return v.ValueToString()
+	return jsonString(v)
 }
 
 func (v *ParserValueBool) GetBool() (bool, error) {
@@ -45,6 +45,6 @@ func (v *ParserValueBool) GetList() ([]ParserValue, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_BOOL)
 }
 
-func (v *ParserValueBool) GetObject() (map[string]ParserValue, error) {
+func (v *ParserValueBool) GetObject() (*OrderedMap, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_BOOL)
 }
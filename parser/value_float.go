@@ -2,6 +2,7 @@ package parser
 
 import (
 	"math/big"
+	"strings"
 )
 
 type ParserValueFloat struct {
@@ -17,11 +18,22 @@ func (v *ParserValueFloat) IsNull() bool {
 }
 
 func (v *ParserValueFloat) ValueToString(indentAndDepth ...int) string {
-	return v.Value.String()
+	s := v.Value.Text('g', -1)
+
+	// make sure the value reads back as a float and not as an int
+	if !strings.Contains(s, ".") && !v.Value.IsInf() {
+		if i := strings.IndexByte(s, 'e'); i != -1 {
+			s = s[:i] + ".0" + s[i:]
+		} else {
+			s += ".0"
+		}
+	}
+
+	return s
 }
 
 func (v *ParserValueFloat) ToJSONString() string {
-	return v.Value.String()
+	return jsonString(v)
 }
 
 func (v *ParserValueFloat) GetFloat() (*big.Float, error) {
@@ -45,6 +57,6 @@ func (v *ParserValueFloat) GetList() ([]ParserValue, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_FLOAT)
 }
 
-func (v *ParserValueFloat) GetObject() (map[string]ParserValue, error) {
+func (v *ParserValueFloat) GetObject() (*OrderedMap, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_FLOAT)
 }
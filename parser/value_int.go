@@ -21,7 +21,7 @@ func (v *ParserValueInt) ValueToString(indentAndDepth ...int) string {
 }
 
 func (v *ParserValueInt) ToJSONString() string {
-	return v.Value.String()
+	return jsonString(v)
 }
 
 func (v *ParserValueInt) GetInt() (*big.Int, error) {
@@ -33,17 +33,17 @@ func (v *ParserValueInt) GetFloat() (*big.Float, error) {
 }
 
 func (v *ParserValueInt) GetString() (string, error) {
-	return "", WrongTypeError(PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_FLOAT)
+	return "", WrongTypeError(PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_INT)
 }
 
 func (v *ParserValueInt) GetBool() (bool, error) {
-	return false, WrongTypeError(PARSER_VALUE_TYPE_BOOL, PARSER_VALUE_TYPE_FLOAT)
+	return false, WrongTypeError(PARSER_VALUE_TYPE_BOOL, PARSER_VALUE_TYPE_INT)
 }
 
 func (v *ParserValueInt) GetList() ([]ParserValue, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_FLOAT)
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_INT)
 }
 
-func (v *ParserValueInt) GetObject() (map[string]ParserValue, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_FLOAT)
+func (v *ParserValueInt) GetObject() (*OrderedMap, error) {
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_INT)
 }
@@ -39,23 +39,7 @@ func (v *ParserValueList) OneLineStringValue() string {
 }
 
 func (v *ParserValueList) ToJSONString() string {
-	if len(v.Value) == 0 {
-		return "[]"
-	}
-
-	s := "["
-
-	for i, val := range v.Value {
-		s += val.ToJSONString()
-
-		if i < len(v.Value)-1 {
-			s += ","
-		}
-	}
-
-	s += "]"
-
-	return s
+	return jsonString(v)
 }
 
 func (v *ParserValueList) ValueToString(indentAndDepth ...int) string {
@@ -122,6 +106,6 @@ func (v *ParserValueList) GetBool() (bool, error) {
 	return false, WrongTypeError(PARSER_VALUE_TYPE_BOOL, PARSER_VALUE_TYPE_LIST)
 }
 
-func (v *ParserValueList) GetObject() (map[string]ParserValue, error) {
+func (v *ParserValueList) GetObject() (*OrderedMap, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_LIST)
 }
@@ -21,7 +21,7 @@ func (v *ParserValueNull) ValueToString(indentAndDepth ...int) string {
 }
 
 func (v *ParserValueNull) ToJSONString() string {
-	return v.ValueToString()
+	return jsonString(v)
 }
 
 func (v *ParserValueNull) GetBool() (bool, error) {
@@ -29,21 +29,21 @@ func (v *ParserValueNull) GetBool() (bool, error) {
 }
 
 func (v *ParserValueNull) GetString() (string, error) {
-	return "", WrongTypeError(PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_BOOL)
+	return "", WrongTypeError(PARSER_VALUE_TYPE_STRING, PARSER_VALUE_TYPE_NULL)
 }
 
 func (v *ParserValueNull) GetFloat() (*big.Float, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_FLOAT, PARSER_VALUE_TYPE_BOOL)
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_FLOAT, PARSER_VALUE_TYPE_NULL)
 }
 
 func (v *ParserValueNull) GetInt() (*big.Int, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_INT, PARSER_VALUE_TYPE_BOOL)
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_INT, PARSER_VALUE_TYPE_NULL)
 }
 
 func (v *ParserValueNull) GetList() ([]ParserValue, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_BOOL)
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_NULL)
 }
 
-func (v *ParserValueNull) GetObject() (map[string]ParserValue, error) {
-	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_BOOL)
+func (v *ParserValueNull) GetObject() (*OrderedMap, error) {
+	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_NULL)
 }
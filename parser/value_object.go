@@ -9,7 +9,7 @@ import (
 )
 
 type ParserValueObject struct {
-	Value map[string]ParserValue
+	Value *OrderedMap
 }
 
 func (v *ParserValueObject) GetType() string {
@@ -21,25 +21,56 @@ func (v *ParserValueObject) IsNull() bool {
 }
 
 func applyEscapes(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\a", "\\a")
-	s = strings.ReplaceAll(s, "\b", "\\b")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\v", "\\v")
-	s = strings.ReplaceAll(s, "\f", "\\f")
-	s = strings.ReplaceAll(s, "\r", "\\r")
+	sb := strings.Builder{}
+
+	for _, c := range s {
+		switch c {
+		case '\\':
+			sb.WriteString("\\\\")
+		case '"':
+			sb.WriteString("\\\"")
+		case '$':
+			// a bare `$` would start a string substitution
+			sb.WriteString("\\$")
+		case '\a':
+			sb.WriteString("\\a")
+		case '\b':
+			sb.WriteString("\\b")
+		case '\t':
+			sb.WriteString("\\t")
+		case '\n':
+			sb.WriteString("\\n")
+		case '\v':
+			sb.WriteString("\\v")
+		case '\f':
+			sb.WriteString("\\f")
+		case '\r':
+			sb.WriteString("\\r")
+		case '\x1b':
+			sb.WriteString("\\e")
+		default:
+			if c < 0x20 || c == 0x7f {
+				sb.WriteString(fmt.Sprintf("\\x%02x", c))
+			} else {
+				sb.WriteRune(c)
+			}
+		}
+	}
 
-	return s
+	return sb.String()
 }
 
-func prepareKey(s string, inJson ...bool) string {
+// FormatKey prints an object key the way it's written in mconf source, only quoted when it has to be
+func FormatKey(key string) string {
+	return prepareKey(key)
+}
+
+func prepareKey(s string) string {
 	if s == "" {
 		return "\"\""
 	}
 
-	if len(inJson) > 0 && inJson[0] || !tokeniser.IsLegalWord([]rune(s)) {
+	if !tokeniser.IsLegalWord([]rune(s)) || tokeniser.IsReservedWord(s) {
 		return fmt.Sprintf("\"%s\"", applyEscapes(s))
 	} else {
 		return s
@@ -47,7 +78,7 @@ func prepareKey(s string, inJson ...bool) string {
 }
 
 func (v *ParserValueObject) OneLineStringValue() string {
-	if len(v.Value) == 0 {
+	if v.Value.Len() == 0 {
 		return "{}"
 	}
 
@@ -55,10 +86,11 @@ func (v *ParserValueObject) OneLineStringValue() string {
 
 	keycount := 0
 
-	for k, val := range v.Value {
+	for _, k := range v.Value.Keys() {
+		val, _ := v.Value.Get(k)
 		s += fmt.Sprintf("%s = %s", prepareKey(k), val.ValueToString())
 
-		if keycount < len(v.Value)-1 {
+		if keycount < v.Value.Len()-1 {
 			s += ", "
 		}
 
@@ -71,27 +103,7 @@ func (v *ParserValueObject) OneLineStringValue() string {
 }
 
 func (v *ParserValueObject) ToJSONString() string {
-	if len(v.Value) == 0 {
-		return "{}"
-	}
-
-	s := "{"
-
-	keycount := 0
-
-	for k, val := range v.Value {
-		s += fmt.Sprintf("%s:%s", prepareKey(k, true), val.ToJSONString())
-
-		if keycount < len(v.Value)-1 {
-			s += ","
-		}
-
-		keycount++
-	}
-
-	s += "}"
-
-	return s
+	return jsonString(v)
 }
 
 func (v *ParserValueObject) ValueToString(indentAndDepth ...int) string {
@@ -108,7 +120,7 @@ func (v *ParserValueObject) ValueToString(indentAndDepth ...int) string {
 		depth = indentAndDepth[1]
 	}
 
-	if len(v.Value) == 0 {
+	if v.Value.Len() == 0 {
 		return "{}"
 	}
 
@@ -125,7 +137,8 @@ func (v *ParserValueObject) ValueToString(indentAndDepth ...int) string {
 	indent := strings.Repeat(" ", indentSize)
 	currindent := strings.Repeat(indent, depth)
 
-	for k, val := range v.Value {
+	for _, k := range v.Value.Keys() {
+		val, _ := v.Value.Get(k)
 		s += fmt.Sprintf("%s%s = %s\n", currindent, prepareKey(k), val.ValueToString(indentSize, depth+1))
 
 		keycount++
@@ -136,7 +149,19 @@ func (v *ParserValueObject) ValueToString(indentAndDepth ...int) string {
 	return s
 }
 
-func (v *ParserValueObject) GetObject() (map[string]ParserValue, error) {
+// TopLevelString renders the object as the contents of a file, with every key as a top-level assignment
+func (v *ParserValueObject) TopLevelString(indentSize int) string {
+	s := ""
+
+	for _, k := range v.Value.Keys() {
+		val, _ := v.Value.Get(k)
+		s += fmt.Sprintf("%s = %s\n", prepareKey(k), val.ValueToString(indentSize, 1))
+	}
+
+	return s
+}
+
+func (v *ParserValueObject) GetObject() (*OrderedMap, error) {
 	return v.Value, nil
 }
 
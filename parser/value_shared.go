@@ -2,8 +2,18 @@ package parser
 
 import (
 	"fmt"
+
+	"github.com/marzeq/mconf/diagnostics"
 )
 
 func WrongTypeError(attemptedType string, actualType string) error {
-	return fmt.Errorf("Tried to get value of type %s, but the underlying value is of type %s\n", attemptedType, actualType)
+	return &diagnostics.TypeError{
+		Diagnostic: diagnostics.Diagnostic{
+			Stage:   diagnostics.STAGE_PARSER,
+			Code:    diagnostics.CODE_TYPE_MISMATCH,
+			Message: fmt.Sprintf("Tried to get value of type %s, but the underlying value is of type %s", attemptedType, actualType),
+		},
+		Expected: attemptedType,
+		Actual:   actualType,
+	}
 }
@@ -2,7 +2,6 @@ package parser
 
 import (
 	"math/big"
-	"strings"
 )
 
 type ParserValueString struct {
@@ -18,17 +17,11 @@ func (v *ParserValueString) IsNull() bool {
 }
 
 func (v *ParserValueString) ValueToString(indentAndDepth ...int) string {
-	replaced := strings.ReplaceAll(v.Value, "\"", "\\\"")
-	replaced = strings.ReplaceAll(replaced, "\n", "\\n")
-	replaced = strings.ReplaceAll(replaced, "\r", "\\r")
-	replaced = strings.ReplaceAll(replaced, "\t", "\\t")
-	replaced = strings.ReplaceAll(replaced, "\f", "\\f")
-
-	return "\"" + replaced + "\""
+	return "\"" + applyEscapes(v.Value) + "\""
 }
 
 func (v *ParserValueString) ToJSONString() string {
-	return v.ValueToString()
+	return jsonString(v)
 }
 
 func (v *ParserValueString) GetString() (string, error) {
@@ -51,6 +44,6 @@ func (v *ParserValueString) GetList() ([]ParserValue, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_LIST, PARSER_VALUE_TYPE_STRING)
 }
 
-func (v *ParserValueString) GetObject() (map[string]ParserValue, error) {
+func (v *ParserValueString) GetObject() (*OrderedMap, error) {
 	return nil, WrongTypeError(PARSER_VALUE_TYPE_OBJECT, PARSER_VALUE_TYPE_STRING)
 }
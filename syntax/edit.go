@@ -0,0 +1,753 @@
+package syntax
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/parser"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+// VALUE_FILENAME is the file name errors in values passed to Set are reported in
+const VALUE_FILENAME = "(value)"
+
+// ImportedKeys returns the top level keys of a file imported without an import list, edits use it to notice keys
+// that come from an import rather than from the edited file
+type ImportedKeys func(importPath string) ([]string, error)
+
+// ImportedKeyError is returned when the edited key is defined by an `@import`, it has to be changed in the imported file
+type ImportedKeyError struct {
+	Path   string
+	Import string
+}
+
+func (e *ImportedKeyError) Error() string {
+	return fmt.Sprintf("Property %s is defined in imported file %s, edit that file instead", e.Path, e.Import)
+}
+
+// NotLiteralError is returned when a path goes through a value that is computed (a constant, a default or a ternary)
+// instead of being written out as an object or list, so there is nothing in the source to edit
+type NotLiteralError struct {
+	Path string
+}
+
+func (e *NotLiteralError) Error() string {
+	return fmt.Sprintf("Property %s is computed from an expression, it can't be edited in place", e.Path)
+}
+
+// MergedError is returned when the edited value is inside of a `+=` that merges it with an earlier definition of it,
+// the result depends on both so there is no single place to edit
+type MergedError struct {
+	Path string
+}
+
+func (e *MergedError) Error() string {
+	return fmt.Sprintf("Property %s is merged with an earlier definition by `+=`, it can't be edited in place", e.Path)
+}
+
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// definition is where a path leads in a file
+type definition struct {
+	// every definition of the key in its object (or at the top level), the last one is the one that counts
+	members []*Member
+
+	list  *List
+	index int
+
+	// when the path isn't defined, object is the innermost object on it that is (nil for the top level),
+	// rest is the part of the path missing from it and walked the part that was found
+	object *Object
+	rest   []string
+	walked string
+
+	// dotted holds the assignments with dotted keys that set something inside of the path (`a.b.c = 1` for `a.b`),
+	// they go away with the value the path had. when the path isn't defined partial is the first of them in the
+	// innermost object, Set turns it into the assignment of the path
+	dotted  []*Member
+	partial *Member
+	// dottedKey is set when the path has to be added with a dotted key, a new object would replace the values
+	// other dotted keys put in the first key of the path
+	dottedKey bool
+}
+
+// Set returns src with the value at path replaced by value, which is the source of a single mconf value. when the key
+// isn't defined yet it is added to the innermost object on the path that is written out in the file (or to the end of the file)
+func Set(src []byte, filename string, path []string, value string, imported ImportedKeys) ([]byte, error) {
+	f, err := Parse(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	valueFile, v, err := parseValueSource(value)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := f.locate(path, imported)
+	if err != nil {
+		return nil, err
+	}
+
+	removals := []edit{}
+
+	for _, m := range def.dotted {
+		if m != def.partial {
+			removals = append(removals, f.memberRemoval(m))
+		}
+	}
+
+	switch {
+	case def.list != nil:
+		element := def.list.Elements[def.index]
+		text := valueFile.formatValue(v, f.lineIndent(element.StartIndex))
+
+		return f.apply(append(removals, edit{element.StartIndex, element.EndIndex, text})), nil
+	case len(def.members) > 0:
+		m := def.members[len(def.members)-1]
+		text := valueFile.formatValue(v, f.lineIndent(m.StartIndex))
+
+		// the value is set, not merged into what was there
+		if m.Assign.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+			removals = append(removals, edit{m.Assign.StartIndex, m.Assign.EndIndex, "="})
+		}
+
+		return f.apply(append(removals, edit{m.Value.StartIndex, m.Value.EndIndex, text})), nil
+	case def.partial != nil:
+		// `a.b.c = 1` becomes `a.b = value` when setting `a.b`
+		m := def.partial
+		text := valueFile.formatValue(v, f.lineIndent(m.StartIndex))
+
+		return f.apply(append(removals, edit{m.KeyPath[len(def.rest)-1].EndIndex, m.Value.EndIndex, " = " + text})), nil
+	case def.object == nil:
+		text := valueFile.memberText(def.rest, v, "", def.dottedKey)
+
+		if len(f.Source) > 0 && f.Source[len(f.Source)-1] != '\n' {
+			text = "\n" + text
+		}
+
+		return f.apply(append(removals, edit{len(f.Source), len(f.Source), text + "\n"})), nil
+	default:
+		o := def.object
+		closeIndent := f.lineIndent(o.Close.StartIndex)
+		inner := closeIndent + FORMAT_INDENT
+
+		if len(o.Members) > 0 && !f.sameLine(o.Open.EndIndex, o.Members[0].StartIndex) {
+			inner = f.lineIndent(o.Members[0].StartIndex)
+		}
+
+		// an object written on one line gets the new member on that line too
+		if len(o.Members) > 0 && f.sameLine(o.Open.EndIndex, o.Close.StartIndex) {
+			end := o.Members[len(o.Members)-1].EndIndex
+			separator := ", "
+
+			if token, ok := f.tokenAt(end); ok && token.Type == tokeniser.TOKEN_TYPE_COMMA {
+				end = token.EndIndex
+				separator = " "
+			}
+
+			text := valueFile.memberText(def.rest, v, closeIndent, def.dottedKey)
+
+			return f.apply(append(removals, edit{end, end, separator + text})), nil
+		}
+
+		text := valueFile.memberText(def.rest, v, inner, def.dottedKey)
+		before := f.whitespaceBefore(o.Close.StartIndex)
+
+		if before == 0 || f.Source[before-1] == '\n' {
+			return f.apply(append(removals, edit{before, before, inner + text + "\n"})), nil
+		}
+
+		return f.apply(append(removals, edit{before, o.Close.StartIndex, "\n" + inner + text + "\n" + closeIndent})), nil
+	}
+}
+
+// Delete returns src without the value at path, when a key is defined more than once every definition of it is removed,
+// and so is every assignment with a dotted key that sets something inside of it
+func Delete(src []byte, filename string, path []string, imported ImportedKeys) ([]byte, error) {
+	f, err := Parse(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := f.locate(path, imported)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.list != nil {
+		return f.apply([]edit{f.elementRemoval(def.list, def.index)}), nil
+	}
+
+	if len(def.members) == 0 && len(def.dotted) == 0 {
+		// with dotted keys around the first missing key may well be defined, only not all the way down
+		if def.dottedKey {
+			return nil, &parser.KeyNotFoundError{Path: parser.FormatPath(path), Key: path[len(path)-1]}
+		}
+
+		return nil, &parser.KeyNotFoundError{Path: parser.AppendPathKey(def.walked, def.rest[0]), Key: def.rest[0]}
+	}
+
+	edits := []edit{}
+
+	for _, m := range def.members {
+		if m.Kind == MEMBER_KIND_DIRECTIVE {
+			return nil, &ImportedKeyError{Path: parser.FormatPath(path), Import: importPath(m)}
+		}
+
+		edits = append(edits, f.memberRemoval(m))
+	}
+
+	for _, m := range def.dotted {
+		edits = append(edits, f.memberRemoval(m))
+	}
+
+	return f.apply(edits), nil
+}
+
+// memberRemoval removes a member together with the comma after it, which belongs to it
+func (f *File) memberRemoval(m *Member) edit {
+	end := m.EndIndex
+
+	if token, ok := f.tokenAt(end); ok && token.Type == tokeniser.TOKEN_TYPE_COMMA {
+		end = token.EndIndex
+	}
+
+	return f.lineRemoval(m.StartIndex, end)
+}
+
+// keyOf returns the key a KEY or STRING token stands for, strings with substitutions can't be known without evaluating them
+func keyOf(token tokeniser.Token) (string, bool) {
+	switch {
+	case token.Type == tokeniser.TOKEN_TYPE_KEY:
+		return token.Value, true
+	case token.Type == tokeniser.TOKEN_TYPE_STRING && len(token.StringSubs) == 0 && len(token.Values) == 1:
+		return token.Values[0], true
+	default:
+		return "", false
+	}
+}
+
+func importPath(m *Member) string {
+	if p, ok := keyOf(m.Path); ok {
+		return p
+	}
+
+	return m.Path.Raw
+}
+
+// keysOf returns the keys of a dotted key, see keyOf
+func keysOf(tokens []tokeniser.Token) ([]string, bool) {
+	keys := []string{}
+
+	for _, token := range tokens {
+		k, ok := keyOf(token)
+		if !ok {
+			return nil, false
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, true
+}
+
+func startsWith(keys []string, prefix []string) bool {
+	if len(prefix) > len(keys) {
+		return false
+	}
+
+	for i, k := range prefix {
+		if keys[i] != k {
+			return false
+		}
+	}
+
+	return true
+}
+
+// membersOnPath finds the assignments among members that are about path: onPath sets path itself or an object it goes
+// through (`a` and `a.b` for `a.b`), dotted sets something inside of it (`a.b.c = 1` for `a.b`)
+func membersOnPath(members []*Member, path []string) (onPath []*Member, dotted []*Member) {
+	for _, m := range members {
+		if m.Kind != MEMBER_KIND_ASSIGNMENT || m.Key.Type == tokeniser.TOKEN_TYPE_CONSTANT {
+			continue
+		}
+
+		keys, ok := keysOf(m.KeyPath)
+		if !ok {
+			continue
+		}
+
+		if startsWith(path, keys) {
+			onPath = append(onPath, m)
+		} else if startsWith(keys, path) {
+			dotted = append(dotted, m)
+		}
+	}
+
+	return onPath, dotted
+}
+
+// keyLength is how many keys of a path a member found by membersOnPath sets, imports only ever set one
+func keyLength(m *Member) int {
+	if m.Kind == MEMBER_KIND_DIRECTIVE {
+		return 1
+	}
+
+	return len(m.KeyPath)
+}
+
+// topLevelDefinitions finds everything at the top level that is about path the way membersOnPath does,
+// looking at assignments, entries of top level blocks and imports
+func (f *File) topLevelDefinitions(path []string, imported ImportedKeys) ([]*Member, []*Member, error) {
+	onPath := []*Member{}
+	dotted := []*Member{}
+
+	for _, m := range f.Members {
+		switch m.Kind {
+		case MEMBER_KIND_ASSIGNMENT, MEMBER_KIND_BLOCK:
+			members := []*Member{m}
+
+			if m.Kind == MEMBER_KIND_BLOCK {
+				members = m.Block.Members
+			}
+
+			found, inside := membersOnPath(members, path)
+			onPath = append(onPath, found...)
+			dotted = append(dotted, inside...)
+		case MEMBER_KIND_DIRECTIVE:
+			provides, err := importProvides(m, path[0], imported)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if provides {
+				onPath = append(onPath, m)
+			}
+		}
+	}
+
+	return onPath, dotted, nil
+}
+
+func importProvides(m *Member, key string, imported ImportedKeys) (bool, error) {
+	if m.Directive.Value != "import" {
+		return false, nil
+	}
+
+	if m.HasImportList {
+		for _, item := range m.Imports {
+			// a selected path is imported under its last key
+			if k, ok := keyOf(item[len(item)-1]); ok && k == key {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	p, ok := keyOf(m.Path)
+	if !ok || imported == nil {
+		return false, nil
+	}
+
+	keys, err := imported(p)
+	if err != nil {
+		return false, fmt.Errorf("Error reading imported file %s to find out whether it defines `%s`: %w", p, key, err)
+	}
+
+	for _, k := range keys {
+		if k == key {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// locate follows path through the file the same way the parser would evaluate it, only looking into values written
+// out as objects and lists
+func (f *File) locate(path []string, imported ImportedKeys) (definition, error) {
+	if len(path) == 0 {
+		return definition{}, fmt.Errorf("No property to edit provided")
+	}
+
+	defs, dotted, err := f.topLevelDefinitions(path, imported)
+	if err != nil {
+		return definition{}, err
+	}
+
+	return f.locateIn(nil, f.assignments(), defs, dotted, nil, path, 0, "")
+}
+
+// assignments returns the assignments at the top level, including the ones in top level blocks
+func (f *File) assignments() []*Member {
+	found := []*Member{}
+
+	for _, m := range f.Members {
+		switch m.Kind {
+		case MEMBER_KIND_ASSIGNMENT:
+			found = append(found, m)
+		case MEMBER_KIND_BLOCK:
+			found = append(found, m.Block.Members...)
+		}
+	}
+
+	return found
+}
+
+// locateIn goes on with locate in object (nil for the top level) with the given members, defs and here are the ones
+// membersOnPath found for path[i:], dotted the ones found on the way to it
+func (f *File) locateIn(object *Object, members []*Member, defs []*Member, here []*Member, dotted []*Member, path []string, i int, walked string) (definition, error) {
+	dotted = append(dotted, here...)
+
+	if len(defs) == 0 {
+		def := definition{object: object, rest: path[i:], walked: walked, dotted: dotted}
+
+		if len(here) > 0 {
+			def.partial = here[0]
+		}
+
+		// nothing sets path[i] itself here, so anything with it as its first key is a dotted key
+		_, sharing := membersOnPath(members, path[i:i+1])
+		def.dottedKey = len(sharing) > 0
+
+		return def, nil
+	}
+
+	last := defs[len(defs)-1]
+
+	if last.Kind == MEMBER_KIND_DIRECTIVE {
+		return definition{}, &ImportedKeyError{Path: parser.AppendPathKey(walked, path[i]), Import: importPath(last)}
+	}
+
+	rest := len(path) - i
+
+	if keyLength(last) == rest {
+		exact := []*Member{}
+
+		for _, m := range defs {
+			if keyLength(m) == rest {
+				exact = append(exact, m)
+			}
+		}
+
+		return definition{members: exact, dotted: dotted}, nil
+	}
+
+	def, err := f.locateInMember(last, dotted, path, i, walked)
+	if err != nil {
+		return definition{}, err
+	}
+
+	if def.found() {
+		return def, f.checkNotMerged(object, members, defs, len(defs)-1, path, i, walked)
+	}
+
+	// `+=` only adds to what was there before, so what the value it merges in doesn't have may be in an earlier definition
+	for j := len(defs) - 1; j > 0 && defs[j].Assign.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN; j-- {
+		earlier := defs[j-1]
+
+		if earlier.Kind != MEMBER_KIND_ASSIGNMENT {
+			break
+		}
+
+		if keyLength(earlier) == rest {
+			return definition{members: []*Member{earlier}, dotted: dotted}, nil
+		}
+
+		earlierDef, earlierErr := f.locateInMember(earlier, dotted, path, i, walked)
+		if earlierErr == nil && earlierDef.found() {
+			return earlierDef, f.checkNotMerged(object, members, defs, j-1, path, i, walked)
+		}
+	}
+
+	return def, nil
+}
+
+// checkNotMerged returns an error when path was found inside the value of defs[j] and that value is merged by `+=` into
+// something the definitions before it may have at path too, editing it in place would leave what they have in the result
+func (f *File) checkNotMerged(object *Object, members []*Member, defs []*Member, j int, path []string, i int, walked string) error {
+	if defs[j].Assign.Type != tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+		return nil
+	}
+
+	earlierDef, err := f.locateIn(object, members, defs[:j], nil, nil, path, i, walked)
+	if err != nil || earlierDef.found() {
+		return &MergedError{Path: parser.FormatPath(path)}
+	}
+
+	return nil
+}
+
+func (d definition) found() bool {
+	return len(d.members) > 0 || d.list != nil || d.partial != nil
+}
+
+// locateInMember goes on with locate in the value of m, an assignment to path[i:] or an object on the way to it
+func (f *File) locateInMember(m *Member, dotted []*Member, path []string, i int, walked string) (definition, error) {
+	for _, seg := range path[i : i+keyLength(m)] {
+		walked = parser.AppendPathKey(walked, seg)
+	}
+
+	i += keyLength(m)
+	value := m.Value
+
+	for {
+		seg := path[i]
+
+		if len(value.Operands) != 1 || value.Operands[0].Token.Type == tokeniser.TOKEN_TYPE_CONSTANT {
+			return definition{}, &NotLiteralError{Path: walked}
+		}
+
+		operand := value.Operands[0]
+
+		if operand.Group != nil || operand.Call != nil || len(operand.Prefix) > 0 {
+			return definition{}, &NotLiteralError{Path: walked}
+		}
+
+		switch {
+		case operand.Object != nil:
+			defs, here := membersOnPath(operand.Object.Members, path[i:])
+
+			return f.locateIn(operand.Object, operand.Object.Members, defs, here, dotted, path, i, walked)
+		case operand.List != nil:
+			walked = fmt.Sprintf("%s[%s]", walked, seg)
+
+			index, err := strconv.Atoi(seg)
+			if err != nil {
+				return definition{}, &parser.InvalidIndexError{Path: walked, Index: seg}
+			}
+
+			if index < 0 || index >= len(operand.List.Elements) {
+				return definition{}, &parser.IndexOutOfRangeError{Path: walked, Index: index, Length: len(operand.List.Elements)}
+			}
+
+			if i == len(path)-1 {
+				return definition{list: operand.List, index: index, dotted: dotted}, nil
+			}
+
+			value = operand.List.Elements[index]
+			i++
+		default:
+			return definition{}, &parser.NotContainerError{Path: parser.AppendPathKey(walked, seg), Type: literalType(operand.Token)}
+		}
+	}
+}
+
+func literalType(token tokeniser.Token) string {
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_STRING:
+		return parser.PARSER_VALUE_TYPE_STRING
+	case tokeniser.TOKEN_TYPE_NUMBER_DECIMAL:
+		if strings.ContainsAny(token.Value, ".eE") {
+			return parser.PARSER_VALUE_TYPE_FLOAT
+		}
+
+		return parser.PARSER_VALUE_TYPE_INT
+	case tokeniser.TOKEN_TYPE_NUMBER_HEX, tokeniser.TOKEN_TYPE_NUMBER_BINARY:
+		return parser.PARSER_VALUE_TYPE_INT
+	case tokeniser.TOKEN_TYPE_BOOL:
+		return parser.PARSER_VALUE_TYPE_BOOL
+	default:
+		return parser.PARSER_VALUE_TYPE_NULL
+	}
+}
+
+// parseValueSource reads src as a single value, like the right hand side of an assignment
+func parseValueSource(src string) (*File, *Value, error) {
+	t := tokeniser.NewTokeniser(src, VALUE_FILENAME, "")
+	tokens, err := t.Tokenise()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := &File{
+		Name:     VALUE_FILENAME,
+		Source:   []rune(src),
+		Tokens:   tokens,
+		Comments: t.Comments(),
+	}
+
+	p := treeParser{file: f}
+
+	v, err := p.ParseValue()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if next := p.Peek(); next.Type != tokeniser.TOKEN_TYPE_EOF {
+		return nil, nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s after the value", next.Type), next)
+	}
+
+	return f, v, nil
+}
+
+// formatValue prints v in the canonical style, lines after the first are indented with indent
+func (f *File) formatValue(v *Value, indent string) string {
+	p := printer{file: f, prefix: indent}
+	p.Value(v)
+
+	return p.sb.String()
+}
+
+// memberText prints `key = value`, nesting objects for every key of path after the first or, when dotted is set,
+// writing all of them as a dotted key
+func (f *File) memberText(path []string, v *Value, indent string, dotted bool) string {
+	key := parser.FormatKey(path[0])
+
+	if dotted {
+		for _, k := range path[1:] {
+			key += "." + parser.FormatKey(k)
+		}
+
+		return key + " = " + f.formatValue(v, indent)
+	}
+
+	if len(path) == 1 {
+		return key + " = " + f.formatValue(v, indent)
+	}
+
+	inner := indent + FORMAT_INDENT
+
+	return key + " = {\n" + inner + f.memberText(path[1:], v, inner, false) + "\n" + indent + "}"
+}
+
+func (f *File) sameLine(from int, to int) bool {
+	return !strings.ContainsRune(string(f.Source[from:to]), '\n')
+}
+
+// whitespaceBefore returns where the run of spaces and tabs ending at offset starts
+func (f *File) whitespaceBefore(offset int) int {
+	for offset > 0 && (f.Source[offset-1] == ' ' || f.Source[offset-1] == '\t') {
+		offset--
+	}
+
+	return offset
+}
+
+// lineIndent returns the spaces and tabs the line containing offset starts with
+func (f *File) lineIndent(offset int) string {
+	start := offset
+
+	for start > 0 && f.Source[start-1] != '\n' {
+		start--
+	}
+
+	end := start
+
+	for end < len(f.Source) && (f.Source[end] == ' ' || f.Source[end] == '\t') {
+		end++
+	}
+
+	return string(f.Source[start:end])
+}
+
+// tokenAt returns the first token starting at or after offset
+func (f *File) tokenAt(offset int) (tokeniser.Token, bool) {
+	i := sort.Search(len(f.Tokens), func(i int) bool { return f.Tokens[i].StartIndex >= offset })
+
+	if i >= len(f.Tokens) {
+		return tokeniser.Token{}, false
+	}
+
+	return f.Tokens[i], true
+}
+
+// tokenBefore returns the last token ending at or before offset
+func (f *File) tokenBefore(offset int) (tokeniser.Token, bool) {
+	i := sort.Search(len(f.Tokens), func(i int) bool { return f.Tokens[i].EndIndex > offset })
+
+	if i == 0 {
+		return tokeniser.Token{}, false
+	}
+
+	return f.Tokens[i-1], true
+}
+
+// lineRemoval removes the source between start and end, together with the line it's on when nothing else is on it
+// (a comment after it included)
+func (f *File) lineRemoval(start int, end int) edit {
+	lineStart := f.whitespaceBefore(start)
+	startsLine := lineStart == 0 || f.Source[lineStart-1] == '\n'
+
+	after := end
+
+	for after < len(f.Source) && (f.Source[after] == ' ' || f.Source[after] == '\t') {
+		after++
+	}
+
+	if startsLine && after < len(f.Source) && f.Source[after] == '#' {
+		for after < len(f.Source) && f.Source[after] != '\n' {
+			after++
+		}
+	}
+
+	endsLine := after == len(f.Source) || f.Source[after] == '\n'
+
+	switch {
+	case startsLine && endsLine:
+		if after < len(f.Source) {
+			after++
+		}
+
+		return edit{lineStart, after, ""}
+	case endsLine:
+		return edit{lineStart, after, ""}
+	default:
+		return edit{start, after, ""}
+	}
+}
+
+func (f *File) elementRemoval(l *List, index int) edit {
+	element := l.Elements[index]
+	start, end := element.StartIndex, element.EndIndex
+
+	if token, ok := f.tokenAt(end); ok && token.Type == tokeniser.TOKEN_TYPE_COMMA {
+		end = token.EndIndex
+	} else if token, ok := f.tokenBefore(start); ok && token.Type == tokeniser.TOKEN_TYPE_COMMA && index > 0 {
+		start = token.StartIndex
+	}
+
+	return f.lineRemoval(start, end)
+}
+
+// apply returns the source with edits made to it, overlapping removals are merged
+func (f *File) apply(edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	merged := []edit{}
+
+	for _, e := range edits {
+		if len(merged) > 0 && e.start < merged[len(merged)-1].end {
+			last := &merged[len(merged)-1]
+			last.end = max(last.end, e.end)
+			last.text += e.text
+
+			continue
+		}
+
+		merged = append(merged, e)
+	}
+
+	sb := strings.Builder{}
+	offset := 0
+
+	for _, e := range merged {
+		sb.WriteString(string(f.Source[offset:e.start]))
+		sb.WriteString(e.text)
+		offset = e.end
+	}
+
+	sb.WriteString(string(f.Source[offset:]))
+
+	return []byte(sb.String())
+}
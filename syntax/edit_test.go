@@ -0,0 +1,133 @@
+package syntax
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetInsideMergeAssignment(t *testing.T) {
+	src := "a = {x = [0]}\na += {x = [1]}\n"
+
+	_, err := Set([]byte(src), "test.mconf", []string{"a", "x"}, "[2]", nil)
+
+	var merged *MergedError
+	if !errors.As(err, &merged) {
+		t.Fatalf("expected a MergedError, got %v", err)
+	}
+
+	// nothing earlier has a.y, so the `+=` alone decides it
+	src = "a = {x = [0]}\na += {y = [1]}\n"
+
+	out, err := Set([]byte(src), "test.mconf", []string{"a", "y"}, "[2]", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := "a = {x = [0]}\na += {y = [2]}\n"; string(out) != expected {
+		t.Errorf("got %q, expected %q", out, expected)
+	}
+}
+
+const editSource = `# config
+db = {
+  host = "a" # the host
+  port = 5432
+}
+servers = [{ host = "x" }, { host = "y" }]
+a.b.c = 1
+{
+  block = 1
+}
+$c = { k = 1 }
+computed = $c
+`
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  []string
+		value string
+		from  string
+		to    string
+	}{
+		{"value", []string{"db", "port"}, "5433", "port = 5432", "port = 5433"},
+		{"keeps comments", []string{"db", "host"}, `"b"`, `host = "a" # the host`, `host = "b" # the host`},
+		{"new key in object", []string{"db", "user"}, `"u"`, "  port = 5432\n", "  port = 5432\n  user = \"u\"\n"},
+		{"new object", []string{"new", "key"}, "1", "computed = $c\n", "computed = $c\nnew = {\n  key = 1\n}\n"},
+		{"list item", []string{"servers", "1", "host"}, `"z"`, `{ host = "y" }`, `{ host = "z" }`},
+		{"dotted key", []string{"a", "b", "c"}, "2", "a.b.c = 1", "a.b.c = 2"},
+		{"next to dotted key", []string{"a", "b", "d"}, "3", "computed = $c\n", "computed = $c\na.b.d = 3\n"},
+		{"top level block", []string{"block"}, "[1, 2]", "block = 1", "block = [1, 2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Set([]byte(editSource), "test.mconf", tt.path, tt.value, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if expected := strings.Replace(editSource, tt.from, tt.to, 1); string(out) != expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", out, expected)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		path []string
+		from string
+	}{
+		{"key with comment", []string{"db", "host"}, "  host = \"a\" # the host\n"},
+		{"object", []string{"db"}, "db = {\n  host = \"a\" # the host\n  port = 5432\n}\n"},
+		{"list item", []string{"servers", "0"}, `{ host = "x" }, `},
+		{"dotted keys", []string{"a", "b"}, "a.b.c = 1\n"},
+		{"top level block", []string{"block"}, "  block = 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Delete([]byte(editSource), "test.mconf", tt.path, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if expected := strings.Replace(editSource, tt.from, "", 1); string(out) != expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", out, expected)
+			}
+		})
+	}
+}
+
+func TestEditErrors(t *testing.T) {
+	imported := func(importPath string) ([]string, error) {
+		return []string{"shared"}, nil
+	}
+
+	src := editSource + "@import \"shared.mconf\"\n"
+
+	_, err := Set([]byte(src), "test.mconf", []string{"computed", "k"}, "2", imported)
+
+	var notLiteral *NotLiteralError
+	if !errors.As(err, &notLiteral) {
+		t.Errorf("expected a NotLiteralError, got %v", err)
+	}
+
+	_, err = Set([]byte(src), "test.mconf", []string{"shared", "x"}, "2", imported)
+
+	var importedErr *ImportedKeyError
+	if !errors.As(err, &importedErr) || importedErr.Import != "shared.mconf" {
+		t.Errorf("expected an ImportedKeyError, got %v", err)
+	}
+
+	if _, err := Delete([]byte(src), "test.mconf", []string{"servers", "5"}, imported); err == nil {
+		t.Error("expected an error for an index out of bounds")
+	}
+
+	if _, err := Set([]byte(src), "test.mconf", []string{"db", "port"}, "5433 +", imported); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
@@ -0,0 +1,382 @@
+package syntax
+
+import (
+	"strings"
+
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+const FORMAT_INDENT = "  "
+
+// Format rewrites src in the canonical style: `=` for assignments, two space indentation, objects spread over
+// multiple lines without commas and keys only quoted when they have to be. comments, blank lines between entries,
+// directives and the way numbers, bools and strings were spelled are all kept
+func Format(src []byte, filename string) ([]byte, error) {
+	f, err := Parse(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Format(), nil
+}
+
+type printer struct {
+	file        *File
+	sb          strings.Builder
+	prefix      string
+	depth       int
+	nextComment int
+	// lastEnd is the offset in the source of the last thing that was printed, used to find blank lines and trailing comments
+	lastEnd int
+}
+
+func (f *File) Format() []byte {
+	p := printer{file: f}
+
+	p.Members(f.Members, len(f.Source))
+
+	out := strings.TrimSpace(p.sb.String())
+
+	if out == "" {
+		return []byte{}
+	}
+
+	return []byte(out + "\n")
+}
+
+func (p *printer) indent() {
+	p.sb.WriteString(p.prefix)
+	p.sb.WriteString(strings.Repeat(FORMAT_INDENT, p.depth))
+}
+
+// blankLineBefore reports whether there is an empty line between the last printed thing and offset in the source
+func (p *printer) blankLineBefore(offset int) bool {
+	return strings.Count(string(p.file.Source[p.lastEnd:offset]), "\n") >= 2
+}
+
+func (p *printer) sameLine(from int, to int) bool {
+	return !strings.ContainsRune(string(p.file.Source[from:to]), '\n')
+}
+
+func (p *printer) peekComment() (tokeniser.Comment, bool) {
+	if p.nextComment >= len(p.file.Comments) {
+		return tokeniser.Comment{}, false
+	}
+
+	return p.file.Comments[p.nextComment], true
+}
+
+// leadingComments prints the comments that come before offset on their own lines
+func (p *printer) leadingComments(offset int, first *bool) {
+	for {
+		c, ok := p.peekComment()
+		if !ok || c.StartIndex >= offset {
+			return
+		}
+
+		if !*first && p.blankLineBefore(c.StartIndex) {
+			p.sb.WriteString("\n")
+		}
+
+		p.indent()
+		p.sb.WriteString(c.Text)
+		p.sb.WriteString("\n")
+
+		p.nextComment++
+		p.lastEnd = c.EndIndex
+		*first = false
+	}
+}
+
+// lineComments finishes the line of something that ended at end, the comment right after it is kept on the same line
+// and the ones stuck in the middle of it (which have nowhere better to go) are printed after it
+func (p *printer) lineComments(end int) {
+	comments := []tokeniser.Comment{}
+
+	for {
+		c, ok := p.peekComment()
+		if !ok {
+			break
+		}
+
+		if c.StartIndex >= end && !p.sameLine(end, c.StartIndex) {
+			break
+		}
+
+		comments = append(comments, c)
+		p.nextComment++
+		p.lastEnd = max(p.lastEnd, c.EndIndex)
+
+		if c.StartIndex >= end {
+			break
+		}
+	}
+
+	for i, c := range comments {
+		if i == 0 {
+			p.sb.WriteString(" ")
+		} else {
+			p.sb.WriteString("\n")
+			p.indent()
+		}
+
+		p.sb.WriteString(c.Text)
+	}
+
+	p.sb.WriteString("\n")
+}
+
+// openComment keeps a comment written right after an opening bracket on the bracket's line, next is the offset of
+// whatever comes after the bracket
+func (p *printer) openComment(open tokeniser.Token, next int) {
+	c, ok := p.peekComment()
+
+	if ok && c.StartIndex < next && p.sameLine(open.EndIndex, c.StartIndex) {
+		p.sb.WriteString(" ")
+		p.sb.WriteString(c.Text)
+
+		p.nextComment++
+		p.lastEnd = c.EndIndex
+	}
+}
+
+func (p *printer) hasCommentBefore(offset int) bool {
+	c, ok := p.peekComment()
+
+	return ok && c.StartIndex < offset
+}
+
+// Members prints a file's or an object's members, one per line, followed by the comments that come before end
+func (p *printer) Members(members []*Member, end int) {
+	first := true
+
+	for _, m := range members {
+		p.leadingComments(m.StartIndex, &first)
+
+		if !first && p.blankLineBefore(m.StartIndex) {
+			p.sb.WriteString("\n")
+		}
+
+		p.indent()
+		p.Member(m)
+
+		p.lastEnd = m.EndIndex
+		p.lineComments(m.EndIndex)
+
+		first = false
+	}
+
+	p.leadingComments(end, &first)
+}
+
+func (p *printer) Member(m *Member) {
+	switch m.Kind {
+	case MEMBER_KIND_ASSIGNMENT:
+		p.sb.WriteString(FormatDeepKey(m.KeyPath))
+
+		if m.Assign.Type == tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+			p.sb.WriteString(" += ")
+		} else {
+			p.sb.WriteString(" = ")
+		}
+
+		p.Value(m.Value)
+	case MEMBER_KIND_BLOCK:
+		p.Object(m.Block)
+	case MEMBER_KIND_DIRECTIVE:
+		p.sb.WriteString(m.Directive.Raw)
+
+		if m.HasImportList {
+			items := []string{}
+
+			for _, item := range m.Imports {
+				items = append(items, FormatDeepKey(item))
+			}
+
+			if len(items) == 0 {
+				p.sb.WriteString(" {}")
+			} else {
+				p.sb.WriteString(" { " + strings.Join(items, ", ") + " }")
+			}
+		}
+
+		if m.Target != nil {
+			p.sb.WriteString(" ")
+			p.sb.WriteString(FormatDeepKey(m.Target))
+		} else {
+			p.sb.WriteString(" ")
+			p.sb.WriteString(m.Path.Raw)
+		}
+	}
+}
+
+// FormatDeepKey prints a dotted path of keys
+func FormatDeepKey(tokens []tokeniser.Token) string {
+	parts := []string{}
+
+	for _, token := range tokens {
+		parts = append(parts, FormatKey(token))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// FormatKey prints a key token, quoted keys lose their quotes when they don't need them
+func FormatKey(token tokeniser.Token) string {
+	if token.Type == tokeniser.TOKEN_TYPE_STRING && len(token.StringSubs) == 0 && len(token.Values) == 1 {
+		key := token.Values[0]
+
+		if key != "" && tokeniser.IsLegalWord([]rune(key)) && !tokeniser.IsReservedWord(key) {
+			return key
+		}
+	}
+
+	return token.Raw
+}
+
+// FormatOperator prints an operator with the spacing it gets in the canonical style
+func FormatOperator(token tokeniser.Token) string {
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_QUESTION_MARK:
+		return "?"
+	default:
+		return " " + token.Raw + " "
+	}
+}
+
+func (p *printer) Value(v *Value) {
+	for i, operand := range v.Operands {
+		if i > 0 {
+			p.sb.WriteString(FormatOperator(v.Operators[i-1]))
+		}
+
+		for _, prefix := range operand.Prefix {
+			p.sb.WriteString(prefix.Raw)
+		}
+
+		switch {
+		case operand.List != nil:
+			p.List(operand.List)
+		case operand.Object != nil:
+			p.Object(operand.Object)
+		case operand.Group != nil:
+			p.sb.WriteString("(")
+			p.Value(operand.Group.Value)
+			p.sb.WriteString(")")
+		case operand.Call != nil:
+			p.sb.WriteString(operand.Call.Name.Raw + "(")
+
+			for j, arg := range operand.Call.Args {
+				if j > 0 {
+					p.sb.WriteString(", ")
+				}
+
+				p.Value(arg)
+			}
+
+			p.sb.WriteString(")")
+		default:
+			p.sb.WriteString(operand.Token.Raw)
+		}
+	}
+}
+
+func (p *printer) Object(o *Object) {
+	if len(o.Members) == 0 && !p.hasCommentBefore(o.Close.StartIndex) {
+		p.sb.WriteString("{}")
+		return
+	}
+
+	p.sb.WriteString("{")
+	p.lastEnd = o.Open.EndIndex
+	if len(o.Members) > 0 {
+		p.openComment(o.Open, o.Members[0].StartIndex)
+	} else {
+		p.openComment(o.Open, o.Close.StartIndex)
+	}
+	p.sb.WriteString("\n")
+
+	p.depth++
+	p.Members(o.Members, o.Close.StartIndex)
+	p.depth--
+
+	p.indent()
+	p.sb.WriteString("}")
+}
+
+// breaks reports whether a list has to be spread over multiple lines, which is when it was written that way,
+// has comments inside of it or holds something that is itself spread over multiple lines
+func (p *printer) breaks(l *List) bool {
+	if len(l.Elements) == 0 {
+		return p.hasCommentBefore(l.Close.StartIndex)
+	}
+
+	if !p.sameLine(l.Open.EndIndex, l.Elements[0].StartIndex) || p.hasCommentBefore(l.Close.StartIndex) {
+		return true
+	}
+
+	for _, element := range l.Elements {
+		for _, operand := range element.Operands {
+			if operand.Object != nil || (operand.List != nil && p.breaks(operand.List)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p *printer) List(l *List) {
+	if !p.breaks(l) {
+		p.sb.WriteString("[")
+
+		for i, element := range l.Elements {
+			if i > 0 {
+				p.sb.WriteString(", ")
+			}
+
+			p.Value(element)
+		}
+
+		p.sb.WriteString("]")
+		return
+	}
+
+	p.sb.WriteString("[")
+	p.lastEnd = l.Open.EndIndex
+	if len(l.Elements) > 0 {
+		p.openComment(l.Open, l.Elements[0].StartIndex)
+	} else {
+		p.openComment(l.Open, l.Close.StartIndex)
+	}
+	p.sb.WriteString("\n")
+
+	p.depth++
+	first := true
+
+	for i, element := range l.Elements {
+		p.leadingComments(element.StartIndex, &first)
+
+		if !first && p.blankLineBefore(element.StartIndex) {
+			p.sb.WriteString("\n")
+		}
+
+		p.indent()
+		p.Value(element)
+
+		if i < len(l.Elements)-1 {
+			p.sb.WriteString(",")
+		}
+
+		p.lastEnd = element.EndIndex
+		p.lineComments(element.EndIndex)
+
+		first = false
+	}
+
+	p.leadingComments(l.Close.StartIndex, &first)
+	p.depth--
+
+	p.indent()
+	p.sb.WriteString("]")
+}
@@ -0,0 +1,137 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/marzeq/mconf/parser"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			"assignments",
+			"a: 1\n$b :2\nc=3",
+			"a = 1\n$b = 2\nc = 3\n",
+		},
+		{
+			"objects",
+			"server: { host = \"a\",  port=1 , nested = {} }",
+			"server = {\n  host = \"a\"\n  port = 1\n  nested = {}\n}\n",
+		},
+		{
+			"keys",
+			"\"plain\" = 1\n\"my key\" = 2\n\"a.b\" = 3",
+			"plain = 1\n\"my key\" = 2\n\"a.b\" = 3\n",
+		},
+		{
+			"comments",
+			"# leading\na = 1 # trailing\nb = {\n  # inside\n  c = 2\n}",
+			"# leading\na = 1 # trailing\nb = {\n  # inside\n  c = 2\n}\n",
+		},
+		{
+			"blank lines",
+			"a = 1\n\n\n\nb = 2\nc = 3\n\n",
+			"a = 1\n\nb = 2\nc = 3\n",
+		},
+		{
+			"lists",
+			"a = [ 1,2 ,3 ]\nb = [\n1, 2]\nc = [1, # one\n2]",
+			"a = [1, 2, 3]\nb = [\n  1,\n  2\n]\nc = [\n  1, # one\n  2\n]\n",
+		},
+		{
+			"spelling",
+			"a = [0x1F, .5, yes, \"a\\tb\"]",
+			"a = [0x1F, .5, yes, \"a\\tb\"]\n",
+		},
+		{
+			"directives",
+			"@import \"a.mconf\"\n@import { x, $y } \"b.mconf\"\n@unset c",
+			"@import \"a.mconf\"\n@import { x, $y } \"b.mconf\"\n@unset c\n",
+		},
+		{
+			"expressions",
+			"a = $x?1+2*( 3-1 )\nb = $p<1024&&!$d?false ~ \"a\"|\"b\"\nc = upper( \"x\" ,1)\nd.e.f += {g=1}",
+			"a = $x?1 + 2 * (3 - 1)\nb = $p < 1024 && !$d?false ~ \"a\" | \"b\"\nc = upper(\"x\", 1)\nd.e.f += {\n  g = 1\n}\n",
+		},
+		{
+			"empty",
+			"\n\n",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Format([]byte(tt.src), "test.mconf")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(out) != tt.expected {
+				t.Fatalf("got:\n%s\nexpected:\n%s", out, tt.expected)
+			}
+
+			again, err := Format(out, "test.mconf")
+			if err != nil {
+				t.Fatalf("unexpected error formatting the output: %v", err)
+			}
+
+			if string(again) != string(out) {
+				t.Errorf("formatting isn't idempotent, got:\n%s\nthe second time", again)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsValues(t *testing.T) {
+	src := `
+		$port: 8080
+		server: { host = "a", "port" = $port + 1, tags = [1,2,
+			3] }
+		"a.b".c = 1
+		level = $port > 1000 ~ "high" | "low"
+		name = "${port}: é"
+	`
+
+	out, err := Format([]byte(src), "test.mconf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parser.Equal(evaluate(t, src), evaluate(t, string(out))) {
+		t.Errorf("formatting changed the values, got:\n%s", out)
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	if _, err := Format([]byte("a = {"), "test.mconf"); err == nil {
+		t.Error("expected an error for an unterminated object")
+	}
+}
+
+// evaluate parses src and returns its values as an object
+func evaluate(t *testing.T, src string) parser.ParserValue {
+	t.Helper()
+
+	tok := tokeniser.NewTokeniser(src, "test.mconf", "")
+
+	tokens, err := tok.Tokenise()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.NewParser(tokens, t.TempDir(), "test.mconf", "")
+	p.SetEnv(map[string]string{})
+
+	values, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &parser.ParserValueObject{Value: values}
+}
@@ -0,0 +1,534 @@
+// Package syntax reads mconf source into a tree that keeps every token and comment without evaluating anything,
+// it is what tools that rewrite source files (like the formatter) are built on
+package syntax
+
+import (
+	"fmt"
+
+	"github.com/marzeq/mconf/diagnostics"
+	"github.com/marzeq/mconf/tokeniser"
+)
+
+const (
+	MEMBER_KIND_ASSIGNMENT = "ASSIGNMENT"
+	MEMBER_KIND_BLOCK      = "BLOCK"
+	MEMBER_KIND_DIRECTIVE  = "DIRECTIVE"
+)
+
+// File is a whole source file
+type File struct {
+	Name     string
+	Source   []rune
+	Tokens   []tokeniser.Token
+	Comments []tokeniser.Comment
+	Members  []*Member
+}
+
+// Member is a statement at the top level of a file or an entry of an object
+type Member struct {
+	Kind string
+
+	// assignments, Key is a KEY, STRING or CONSTANT token. KeyPath holds every key of a dotted key (`a.b.c = 1`),
+	// Key being the first one, for any other key it only holds Key. Assign is the `=` or `+=` token
+	Key     tokeniser.Token
+	KeyPath []tokeniser.Token
+	Assign  tokeniser.Token
+	Value   *Value
+
+	// top level `{ ... }` blocks
+	Block *Object
+
+	// directives, for `@import` Imports holds the tokens of every selected path or constant when HasImportList is set,
+	// for `@unset` Target holds the keys of the removed path
+	Directive     tokeniser.Token
+	HasImportList bool
+	Imports       [][]tokeniser.Token
+	Path          tokeniser.Token
+	Target        []tokeniser.Token
+
+	// StartIndex and EndIndex are the (rune) offsets of the member in the source, an optional trailing comma isn't included
+	StartIndex int
+	EndIndex   int
+}
+
+// Value is an operand, optionally followed by operators and more operands (`$a ? "default"`, `$b ~ 1 | 2`, `$c * 2`)
+type Value struct {
+	Operands  []*Operand
+	Operators []tokeniser.Token
+
+	StartIndex int
+	EndIndex   int
+}
+
+// Operand is either a single token (a literal or a constant), a list, an object, a parenthesised value or a function call,
+// Prefix holds the unary operators in front of it (`-$a`, `!$b`)
+type Operand struct {
+	Prefix []tokeniser.Token
+	Token  tokeniser.Token
+	List   *List
+	Object *Object
+	Group  *Group
+	Call   *Call
+}
+
+// Group is a value in parentheses
+type Group struct {
+	Open  tokeniser.Token
+	Close tokeniser.Token
+	Value *Value
+}
+
+// Call is a call of a built-in function (`upper($name)`), Name is the KEY token of the function
+type Call struct {
+	Name  tokeniser.Token
+	Open  tokeniser.Token
+	Close tokeniser.Token
+	Args  []*Value
+}
+
+type List struct {
+	Open     tokeniser.Token
+	Close    tokeniser.Token
+	Elements []*Value
+}
+
+type Object struct {
+	Open    tokeniser.Token
+	Close   tokeniser.Token
+	Members []*Member
+}
+
+type treeParser struct {
+	file      *File
+	currIndex int
+}
+
+// Parse reads src into a File, filename is only used in error messages
+func Parse(src []byte, filename string) (*File, error) {
+	s := string(src)
+
+	t := tokeniser.NewTokeniser(s, filename, "")
+	tokens, err := t.Tokenise()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Name:     filename,
+		Source:   []rune(s),
+		Tokens:   tokens,
+		Comments: t.Comments(),
+	}
+
+	p := treeParser{file: f}
+
+	for p.Peek().Type != tokeniser.TOKEN_TYPE_EOF {
+		member, err := p.ParseMember(true)
+		if err != nil {
+			return nil, err
+		}
+
+		f.Members = append(f.Members, member)
+	}
+
+	return f, nil
+}
+
+func (p *treeParser) Peek() tokeniser.Token {
+	if p.currIndex >= len(p.file.Tokens) {
+		eof := tokeniser.EOFToken()
+		eof.StartIndex = len(p.file.Source)
+		eof.EndIndex = len(p.file.Source)
+
+		return eof
+	}
+
+	return p.file.Tokens[p.currIndex]
+}
+
+func (p *treeParser) Consume() tokeniser.Token {
+	token := p.Peek()
+	p.currIndex++
+
+	return token
+}
+
+func (p *treeParser) FormatErrorAtToken(code string, message string, token tokeniser.Token) error {
+	prettyFile := p.file.Name
+
+	if prettyFile == "" {
+		prettyFile = "(stdin)"
+	}
+
+	return &diagnostics.SyntaxError{
+		Diagnostic: diagnostics.Diagnostic{
+			Position: diagnostics.Position{File: prettyFile, Line: token.Start.Line, Col: token.Start.Col},
+			Stage:    diagnostics.STAGE_PARSER,
+			Code:     code,
+			Message:  message,
+		},
+	}
+}
+
+func (p *treeParser) unexpected(token tokeniser.Token) error {
+	return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, fmt.Sprintf("Unexpected token %s", token.Type), token)
+}
+
+// ParseMember reads one statement (topLevel) or one object entry, the same forms the parser accepts
+func (p *treeParser) ParseMember(topLevel bool) (*Member, error) {
+	token := p.Consume()
+
+	member := &Member{StartIndex: token.StartIndex}
+
+	switch {
+	case token.Type == tokeniser.TOKEN_TYPE_KEY || token.Type == tokeniser.TOKEN_TYPE_STRING || (topLevel && token.Type == tokeniser.TOKEN_TYPE_CONSTANT):
+		member.KeyPath = []tokeniser.Token{token}
+
+		if token.Type != tokeniser.TOKEN_TYPE_CONSTANT {
+			p.currIndex--
+
+			keyPath, err := p.ParseDeepKey()
+			if err != nil {
+				return nil, err
+			}
+
+			member.KeyPath = keyPath
+		}
+
+		assign := p.Consume()
+
+		if assign.Type != tokeniser.TOKEN_TYPE_ASSIGN && assign.Type != tokeniser.TOKEN_TYPE_MERGE_ASSIGN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected assignment operator `=` or `+=`", assign)
+		}
+
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		member.Kind = MEMBER_KIND_ASSIGNMENT
+		member.Key = token
+		member.Assign = assign
+		member.Value = value
+		member.EndIndex = value.EndIndex
+	case topLevel && token.Type == tokeniser.TOKEN_TYPE_OPEN_OBJ:
+		object, err := p.ParseObject(token)
+		if err != nil {
+			return nil, err
+		}
+
+		member.Kind = MEMBER_KIND_BLOCK
+		member.Block = object
+		member.EndIndex = object.Close.EndIndex
+	case topLevel && token.Type == tokeniser.TOKEN_TYPE_DIRECTIVE:
+		member.Kind = MEMBER_KIND_DIRECTIVE
+		member.Directive = token
+
+		err := p.ParseDirective(member)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.unexpected(token)
+	}
+
+	if !topLevel && p.Peek().Type == tokeniser.TOKEN_TYPE_COMMA {
+		p.currIndex++
+	}
+
+	return member, nil
+}
+
+func (p *treeParser) ParseDirective(member *Member) error {
+	switch member.Directive.Value {
+	case "import":
+		if p.Peek().Type == tokeniser.TOKEN_TYPE_OPEN_OBJ {
+			p.currIndex++
+			member.HasImportList = true
+
+			for {
+				token := p.Peek()
+
+				if token.Type == tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+					p.currIndex++
+					break
+				}
+
+				var item []tokeniser.Token
+
+				if token.Type == tokeniser.TOKEN_TYPE_CONSTANT {
+					item = append(item, p.Consume())
+				} else {
+					deepKey, err := p.ParseDeepKey()
+					if err != nil {
+						return err
+					}
+
+					item = deepKey
+				}
+
+				member.Imports = append(member.Imports, item)
+
+				commaOrClose := p.Peek()
+
+				if commaOrClose.Type == tokeniser.TOKEN_TYPE_COMMA {
+					p.currIndex++
+				} else if commaOrClose.Type != tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+					return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing bracket", commaOrClose)
+				}
+			}
+		}
+
+		path := p.Consume()
+
+		if path.Type != tokeniser.TOKEN_TYPE_STRING {
+			return p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected string path to import", path)
+		}
+
+		member.Path = path
+		member.EndIndex = path.EndIndex
+	case "unset":
+		target, err := p.ParseDeepKey()
+		if err != nil {
+			return err
+		}
+
+		member.Target = target
+		member.EndIndex = target[len(target)-1].EndIndex
+	default:
+		return p.FormatErrorAtToken(diagnostics.CODE_UNKNOWN_DIRECTIVE, fmt.Sprintf("Unknown directive `%s`", member.Directive.Value), member.Directive)
+	}
+
+	return nil
+}
+
+// ParseDeepKey reads a dotted path of keys like `a.b."c"`
+func (p *treeParser) ParseDeepKey() ([]tokeniser.Token, error) {
+	key := []tokeniser.Token{}
+
+	for {
+		part := p.Consume()
+
+		if part.Type != tokeniser.TOKEN_TYPE_KEY && part.Type != tokeniser.TOKEN_TYPE_STRING {
+			return nil, p.unexpected(part)
+		}
+
+		key = append(key, part)
+
+		if p.Peek().Type != tokeniser.TOKEN_TYPE_DOT {
+			return key, nil
+		}
+
+		p.currIndex++
+	}
+}
+
+// IsOperator reports whether a token joins two operands of a value
+func IsOperator(token tokeniser.Token) bool {
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_QUESTION_MARK, tokeniser.TOKEN_TYPE_TILDE, tokeniser.TOKEN_TYPE_PIPE,
+		tokeniser.TOKEN_TYPE_PLUS, tokeniser.TOKEN_TYPE_MINUS, tokeniser.TOKEN_TYPE_STAR, tokeniser.TOKEN_TYPE_SLASH, tokeniser.TOKEN_TYPE_PERCENT,
+		tokeniser.TOKEN_TYPE_EQUAL, tokeniser.TOKEN_TYPE_NOT_EQUAL, tokeniser.TOKEN_TYPE_LESS, tokeniser.TOKEN_TYPE_LESS_EQUAL,
+		tokeniser.TOKEN_TYPE_GREATER, tokeniser.TOKEN_TYPE_GREATER_EQUAL, tokeniser.TOKEN_TYPE_AND, tokeniser.TOKEN_TYPE_OR:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *treeParser) ParseValue() (*Value, error) {
+	value := &Value{}
+
+	for {
+		operand, err := p.ParseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		value.Operands = append(value.Operands, operand)
+
+		if !IsOperator(p.Peek()) {
+			break
+		}
+
+		value.Operators = append(value.Operators, p.Consume())
+	}
+
+	value.StartIndex = value.Operands[0].StartIndex()
+	value.EndIndex = value.Operands[len(value.Operands)-1].EndIndex()
+
+	return value, nil
+}
+
+func (p *treeParser) ParseOperand() (*Operand, error) {
+	prefix := []tokeniser.Token{}
+
+	for p.Peek().Type == tokeniser.TOKEN_TYPE_MINUS || p.Peek().Type == tokeniser.TOKEN_TYPE_NOT {
+		prefix = append(prefix, p.Consume())
+	}
+
+	operand, err := p.parsePlainOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prefix) > 0 {
+		operand.Prefix = prefix
+	}
+
+	return operand, nil
+}
+
+func (p *treeParser) parsePlainOperand() (*Operand, error) {
+	token := p.Consume()
+
+	switch token.Type {
+	case tokeniser.TOKEN_TYPE_STRING,
+		tokeniser.TOKEN_TYPE_NUMBER_DECIMAL,
+		tokeniser.TOKEN_TYPE_NUMBER_HEX,
+		tokeniser.TOKEN_TYPE_NUMBER_BINARY,
+		tokeniser.TOKEN_TYPE_BOOL,
+		tokeniser.TOKEN_TYPE_NULL,
+		tokeniser.TOKEN_TYPE_CONSTANT:
+		return &Operand{Token: token}, nil
+	case tokeniser.TOKEN_TYPE_OPEN_LIST:
+		list, err := p.ParseList(token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Operand{Token: token, List: list}, nil
+	case tokeniser.TOKEN_TYPE_OPEN_OBJ:
+		object, err := p.ParseObject(token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Operand{Token: token, Object: object}, nil
+	case tokeniser.TOKEN_TYPE_OPEN_PAREN:
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		closeParen := p.Consume()
+
+		if closeParen.Type != tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected closing parenthesis `)`", closeParen)
+		}
+
+		return &Operand{Token: token, Group: &Group{Open: token, Close: closeParen, Value: value}}, nil
+	case tokeniser.TOKEN_TYPE_KEY:
+		if p.Peek().Type != tokeniser.TOKEN_TYPE_OPEN_PAREN {
+			return nil, p.unexpected(token)
+		}
+
+		call, err := p.ParseCall(token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Operand{Token: token, Call: call}, nil
+	default:
+		return nil, p.unexpected(token)
+	}
+}
+
+func (p *treeParser) ParseList(open tokeniser.Token) (*List, error) {
+	list := &List{Open: open}
+
+	for {
+		if p.Peek().Type == tokeniser.TOKEN_TYPE_CLOSE_LIST {
+			list.Close = p.Consume()
+			return list, nil
+		}
+
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		list.Elements = append(list.Elements, value)
+
+		commaOrClose := p.Peek()
+
+		if commaOrClose.Type == tokeniser.TOKEN_TYPE_COMMA {
+			p.currIndex++
+		} else if commaOrClose.Type != tokeniser.TOKEN_TYPE_CLOSE_LIST {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing bracket", commaOrClose)
+		}
+	}
+}
+
+func (p *treeParser) ParseCall(name tokeniser.Token) (*Call, error) {
+	call := &Call{Name: name, Open: p.Consume()}
+
+	for {
+		if p.Peek().Type == tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+			call.Close = p.Consume()
+			return call, nil
+		}
+
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		call.Args = append(call.Args, value)
+
+		commaOrClose := p.Peek()
+
+		if commaOrClose.Type == tokeniser.TOKEN_TYPE_COMMA {
+			p.currIndex++
+		} else if commaOrClose.Type != tokeniser.TOKEN_TYPE_CLOSE_PAREN {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Expected comma or closing parenthesis `)`", commaOrClose)
+		}
+	}
+}
+
+func (p *treeParser) ParseObject(open tokeniser.Token) (*Object, error) {
+	object := &Object{Open: open}
+
+	for {
+		token := p.Peek()
+
+		if token.Type == tokeniser.TOKEN_TYPE_CLOSE_OBJ {
+			object.Close = p.Consume()
+			return object, nil
+		}
+
+		if token.Type == tokeniser.TOKEN_TYPE_EOF {
+			return nil, p.FormatErrorAtToken(diagnostics.CODE_UNEXPECTED_TOKEN, "Unexpected end of file, expected closing bracket `}`", token)
+		}
+
+		member, err := p.ParseMember(false)
+		if err != nil {
+			return nil, err
+		}
+
+		object.Members = append(object.Members, member)
+	}
+}
+
+func (o *Operand) StartIndex() int {
+	if len(o.Prefix) > 0 {
+		return o.Prefix[0].StartIndex
+	}
+
+	return o.Token.StartIndex
+}
+
+func (o *Operand) EndIndex() int {
+	switch {
+	case o.List != nil:
+		return o.List.Close.EndIndex
+	case o.Object != nil:
+		return o.Object.Close.EndIndex
+	case o.Group != nil:
+		return o.Group.Close.EndIndex
+	case o.Call != nil:
+		return o.Call.Close.EndIndex
+	default:
+		return o.Token.EndIndex
+	}
+}
@@ -8,6 +8,7 @@ const (
 	TOKEN_TYPE_KEY            = "KEY"
 	TOKEN_TYPE_CONSTANT       = "CONSTANT"
 	TOKEN_TYPE_ASSIGN         = "ASSIGN"
+	TOKEN_TYPE_MERGE_ASSIGN   = "MERGE_ASSIGN"
 	TOKEN_TYPE_NUMBER_DECIMAL = "NUMBER_DECIMAL"
 	TOKEN_TYPE_NUMBER_HEX     = "NUMBER_HEX"
 	TOKEN_TYPE_NUMBER_BINARY  = "NUMBER_BINARY"
@@ -21,9 +22,26 @@ const (
 	TOKEN_TYPE_QUESTION_MARK  = "QUESTION_MARK"
 	TOKEN_TYPE_TILDE          = "TILDE"
 	TOKEN_TYPE_PIPE           = "PIPE"
+	TOKEN_TYPE_PLUS           = "PLUS"
+	TOKEN_TYPE_MINUS          = "MINUS"
+	TOKEN_TYPE_STAR           = "STAR"
+	TOKEN_TYPE_SLASH          = "SLASH"
+	TOKEN_TYPE_PERCENT        = "PERCENT"
+	TOKEN_TYPE_OPEN_PAREN     = "OPEN_PAREN"
+	TOKEN_TYPE_CLOSE_PAREN    = "CLOSE_PAREN"
+	TOKEN_TYPE_EQUAL          = "EQUAL"
+	TOKEN_TYPE_NOT_EQUAL      = "NOT_EQUAL"
+	TOKEN_TYPE_LESS           = "LESS"
+	TOKEN_TYPE_LESS_EQUAL     = "LESS_EQUAL"
+	TOKEN_TYPE_GREATER        = "GREATER"
+	TOKEN_TYPE_GREATER_EQUAL  = "GREATER_EQUAL"
+	TOKEN_TYPE_AND            = "AND"
+	TOKEN_TYPE_OR             = "OR"
+	TOKEN_TYPE_NOT            = "NOT"
 	TOKEN_TYPE_OPEN_OBJ       = "OPEN_OBJ"
 	TOKEN_TYPE_CLOSE_OBJ      = "CLOSE_OBJ"
 	TOKEN_TYPE_DIRECTIVE      = "DIRECTIVE"
+	TOKEN_TYPE_INVALID        = "INVALID"
 
 	TOKEN_TYPE_EOF = "EOF"
 )
@@ -47,6 +65,19 @@ type Token struct {
 	Values     []string
 	StringSubs []string
 	Start      Location
+
+	// Raw is the token exactly as it was written, StartIndex and EndIndex are the (rune) offsets of it in the source
+	Raw        string
+	StartIndex int
+	EndIndex   int
+}
+
+// Comment is a `#` comment, the tokeniser doesn't turn them into tokens but keeps them around for tools that rewrite source files
+type Comment struct {
+	Text       string
+	Start      Location
+	StartIndex int
+	EndIndex   int
 }
 
 func (t Token) Repr() string {
@@ -87,6 +118,14 @@ func AssignToken(start Location) Token {
 	}
 }
 
+func MergeAssignToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_MERGE_ASSIGN,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
 func NumberToken(value string, numtype string, start Location) Token {
 	switch numtype {
 	case TOKEN_TYPE_NUMBER_DECIMAL:
@@ -197,6 +236,134 @@ func PipeToken(start Location) Token {
 	}
 }
 
+func PlusToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_PLUS,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func MinusToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_MINUS,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func StarToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_STAR,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func SlashToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_SLASH,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func PercentToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_PERCENT,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func OpenParenToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_OPEN_PAREN,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func CloseParenToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_CLOSE_PAREN,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func EqualToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_EQUAL,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func NotEqualToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_NOT_EQUAL,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func LessToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_LESS,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func LessEqualToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_LESS_EQUAL,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func GreaterToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_GREATER,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func GreaterEqualToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_GREATER_EQUAL,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func AndToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_AND,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func OrToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_OR,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
+func NotToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_NOT,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
 func OpenObjToken(start Location) Token {
 	return Token{
 		Type:  TOKEN_TYPE_OPEN_OBJ,
@@ -221,6 +388,15 @@ func DirectiveToken(value string, start Location) Token {
 	}
 }
 
+// InvalidToken stands in for input the tokeniser already reported an error about
+func InvalidToken(start Location) Token {
+	return Token{
+		Type:  TOKEN_TYPE_INVALID,
+		Value: NO_VALUE,
+		Start: start,
+	}
+}
+
 func EOFToken() Token {
 	return Token{
 		Type:  TOKEN_TYPE_EOF,
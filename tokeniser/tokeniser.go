@@ -2,9 +2,11 @@ package tokeniser
 
 import (
 	"fmt"
-	"os"
 	"path"
+	"strings"
 	"unicode"
+
+	"github.com/marzeq/mconf/diagnostics"
 )
 
 type Tokeniser struct {
@@ -12,6 +14,8 @@ type Tokeniser struct {
 	currIndex   int
 	filePath    string
 	relativeDir string
+	errors      diagnostics.List
+	comments    []Comment
 }
 
 func NewTokeniser(contents string, filePath string, relativeDir string) Tokeniser {
@@ -35,8 +39,11 @@ func (t *Tokeniser) Peek() rune {
 	return t.PeekAhead(0)
 }
 
+// Increment moves to the next character, reading never goes past the end of the input
 func (t *Tokeniser) Increment() {
-	t.currIndex++
+	if t.currIndex < len(t.contents) {
+		t.currIndex++
+	}
 }
 
 func (t *Tokeniser) Consume() rune {
@@ -51,6 +58,13 @@ func (t *Tokeniser) GoBack() {
 	t.currIndex--
 }
 
+// Unread steps back over c, which was just consumed. at the end of the input Consume doesn't move, so there's nothing to step back over
+func (t *Tokeniser) Unread(c rune) {
+	if c != 0 {
+		t.GoBack()
+	}
+}
+
 func IsHexDigit(c rune) bool {
 	return IsAsciiDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
@@ -58,25 +72,25 @@ func IsHexDigit(c rune) bool {
 func (t *Tokeniser) ReadUnicodeEscape(backslashULoc Location) (rune, error) {
 	hc1 := t.Consume()
 	if !IsHexDigit(hc1) {
-		return 0, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\u`, got `%c`", hc1), backslashULoc)
+		return 0, t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\u`, got %s", describeRune(hc1)), backslashULoc)
 	}
 	h1 := hexToDec(hc1)
 
 	hc2 := t.Consume()
 	if !IsHexDigit(hc2) {
-		return 0, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\u%c`, got `%c`", hc1, hc2), backslashULoc)
+		return 0, t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\u%c`, got %s", hc1, describeRune(hc2)), backslashULoc)
 	}
 	h2 := hexToDec(hc2)
 
 	hc3 := t.Consume()
 	if !IsHexDigit(hc3) {
-		return 0, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\u%c%c`, got `%c`", hc1, hc2, hc3), backslashULoc)
+		return 0, t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\u%c%c`, got %s", hc1, hc2, describeRune(hc3)), backslashULoc)
 	}
 	h3 := hexToDec(hc3)
 
 	hc4 := t.Consume()
 	if !IsHexDigit(hc4) {
-		return 0, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\u%c%c%c`, got `%c`", hc1, hc2, hc3, hc4), backslashULoc)
+		return 0, t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\u%c%c%c`, got %s", hc1, hc2, hc3, describeRune(hc4)), backslashULoc)
 	}
 	h4 := hexToDec(hc4)
 
@@ -110,6 +124,15 @@ func (t *Tokeniser) ReadUnicodeEscape(backslashULoc Location) (rune, error) {
 	return rune(ch), nil
 }
 
+// describeRune quotes c for an error message, or says that the input ended
+func describeRune(c rune) string {
+	if c == 0 {
+		return "end of file"
+	}
+
+	return fmt.Sprintf("`%c`", c)
+}
+
 func hexToDec(hc rune) int {
 	if hc >= '0' && hc <= '9' {
 		return int(hc - '0')
@@ -132,33 +155,40 @@ func (t *Tokeniser) ReadString() ([]string, []string, error) {
 	constantSubs := []string{}
 
 	if initial != '"' {
-		return nil, nil, t.FormatErrorAt("Expected `\"` to start string", loc)
+		return nil, nil, t.FormatErrorAt(diagnostics.CODE_UNEXPECTED_CHARACTER, "Expected `\"` to start string", loc)
 	}
 
 	for {
 		c := t.Consume()
 
 		if c == 0 {
-			return nil, nil, t.FormatErrorAt("Unexpected end of file in string", loc)
+			return nil, nil, t.FormatErrorAt(diagnostics.CODE_UNTERMINATED_STRING, "Unexpected end of file in string", loc)
 		}
 
 		if c == '$' {
 			openbrack := t.Consume()
 
+			// errors inside of a string are recorded and the rest of the string is still read, so the tokeniser doesn't lose track of where it ends
 			if openbrack != '{' {
-				return nil, nil, t.FormatErrorAt("Expected `{` after `$` in formatted string", loc)
+				t.errors.Add(t.FormatErrorAt(diagnostics.CODE_INVALID_SUBSTITUTION, "Expected `{` after `$` in formatted string", loc))
+				t.Unread(openbrack)
+				continue
 			}
 
+			wordStart := t.currIndex
 			constantName, error := t.ReadWord()
 
 			if error != nil {
-				return nil, nil, error
+				t.errors.Add(error)
+				t.currIndex = wordStart
+				continue
 			}
 
 			closebrack := t.Consume()
 
 			if closebrack != '}' {
-				return nil, nil, t.FormatErrorAt("Expected `}` after constant name in formatted string", loc)
+				t.errors.Add(t.FormatErrorAt(diagnostics.CODE_INVALID_SUBSTITUTION, "Expected `}` after constant name in formatted string", loc))
+				t.Unread(closebrack)
 			}
 
 			constantSubs = append(constantSubs, constantName)
@@ -170,6 +200,11 @@ func (t *Tokeniser) ReadString() ([]string, []string, error) {
 			nextloc := t.GetCurrLineAndCol()
 			next := t.Consume()
 
+			// the string isn't terminated, which is reported on the next loop
+			if next == 0 {
+				continue
+			}
+
 			switch next {
 			case '"':
 				strings[len(strings)-1] += "\""
@@ -200,27 +235,34 @@ func (t *Tokeniser) ReadString() ([]string, []string, error) {
 			case 'X':
 				hc1 := t.Consume()
 				if !IsHexDigit(hc1) {
-					return nil, nil, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\x`, got `%c`", hc1), nextloc)
+					t.errors.Add(t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\x`, got %s", describeRune(hc1)), nextloc))
+					t.Unread(hc1)
+					continue
 				}
 				h1 := hexToDec(hc1)
 				hc2 := t.Consume()
 				if !IsHexDigit(hc2) {
-					return nil, nil, t.FormatErrorAt(fmt.Sprintf("Expected hex digit after `\\x%c`, got `%c`", hc1, hc2), nextloc)
+					t.errors.Add(t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Expected hex digit after `\\x%c`, got %s", hc1, describeRune(hc2)), nextloc))
+					t.Unread(hc2)
+					continue
 				}
 				h2 := hexToDec(hc2)
 				strings[len(strings)-1] += string(rune(h1*16 + h2))
 			case 'u':
 				fallthrough
 			case 'U':
+				escapeStart := t.currIndex
 				unicodeChar, err := t.ReadUnicodeEscape(nextloc)
 				if err != nil {
-					return nil, nil, err
+					t.errors.Add(err)
+					t.currIndex = max(escapeStart, t.currIndex-1)
+					continue
 				}
 				strings[len(strings)-1] += string(unicodeChar)
 			case '$':
 				strings[len(strings)-1] += "$"
 			default:
-				return nil, nil, t.FormatErrorAt(fmt.Sprintf("Unknown escape sequence: `\\%c`", next), nextloc)
+				t.errors.Add(t.FormatErrorAt(diagnostics.CODE_INVALID_ESCAPE, fmt.Sprintf("Unknown escape sequence: `\\%c`", next), nextloc))
 			}
 		} else if c == initial {
 			break
@@ -237,7 +279,7 @@ func (t *Tokeniser) ReadWord() (string, error) {
 	initial := t.Consume()
 
 	if !IsLegalWordStart(initial) {
-		return "", t.FormatErrorAt("Expected letter to start a word", loc)
+		return "", t.FormatErrorAt(diagnostics.CODE_UNEXPECTED_CHARACTER, "Expected letter to start a word", loc)
 	}
 
 	word := string(initial)
@@ -261,7 +303,7 @@ func (t *Tokeniser) ReadNumber() (string, string, error) {
 	initial := t.Consume()
 
 	if !IsAsciiDigit(initial) && initial != '-' && initial != '.' {
-		return "", "", t.FormatErrorAt("Expected digit to start a number", loc)
+		return "", "", t.FormatErrorAt(diagnostics.CODE_INVALID_NUMBER, "Expected digit to start a number", loc)
 	}
 
 	number := string(initial)
@@ -313,7 +355,7 @@ func (t *Tokeniser) ReadNumber() (string, string, error) {
 
 				next = t.Peek()
 				if !unicode.IsDigit(next) {
-					return "", "", t.FormatError(fmt.Sprintf("Expected digit after exponent in decimal number, got `%c`", next))
+					return "", "", t.FormatError(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Expected digit after exponent in decimal number, got `%c`", next))
 				}
 
 				number += string(next)
@@ -321,7 +363,7 @@ func (t *Tokeniser) ReadNumber() (string, string, error) {
 			} else if next == '_' {
 				t.Increment()
 			} else if unicode.IsLetter(next) {
-				return "", "", t.FormatError(fmt.Sprintf("Unexpected character in decimal number: `%c`", next))
+				return "", "", t.FormatError(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Unexpected character in decimal number: `%c`", next))
 			} else {
 				break
 			}
@@ -332,7 +374,7 @@ func (t *Tokeniser) ReadNumber() (string, string, error) {
 			} else if next == '_' {
 				t.Increment()
 			} else if unicode.IsLetter(next) {
-				return "", "", t.FormatError(fmt.Sprintf("Unexpected character in hex number: `%c`", next))
+				return "", "", t.FormatError(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Unexpected character in hex number: `%c`", next))
 			} else {
 				break
 			}
@@ -343,24 +385,26 @@ func (t *Tokeniser) ReadNumber() (string, string, error) {
 			} else if next == '_' {
 				t.Increment()
 			} else if unicode.IsLetter(next) {
-				return "", "", t.FormatError(fmt.Sprintf("Unexpected character in binary number: `%c`", next))
+				return "", "", t.FormatError(diagnostics.CODE_INVALID_NUMBER, fmt.Sprintf("Unexpected character in binary number: `%c`", next))
 			} else {
 				break
 			}
 		} else {
-			return "", "", t.FormatError(fmt.Sprintf("Unreachable code reached, this is a bug, please report it"))
+			return "", "", t.FormatError(diagnostics.CODE_INTERNAL, "Unreachable code reached, this is a bug, please report it")
 		}
 	}
 
 	return number, mode, nil
 }
 
-func (t *Tokeniser) IgnoreComment() error {
+// ReadComment skips over a comment, remembering it so that it can be returned by Comments
+func (t *Tokeniser) ReadComment() error {
 	loc := t.GetCurrLineAndCol()
+	startIndex := t.currIndex
 	initial := t.Consume()
 
 	if initial != '#' {
-		return t.FormatErrorAt("Expected `#` to start a comment, this is a bug, please report it", loc)
+		return t.FormatErrorAt(diagnostics.CODE_INTERNAL, "Expected `#` to start a comment, this is a bug, please report it", loc)
 	}
 
 	for {
@@ -373,14 +417,35 @@ func (t *Tokeniser) IgnoreComment() error {
 		t.Increment()
 	}
 
+	t.comments = append(t.comments, Comment{
+		Text:       strings.TrimRightFunc(string(t.contents[startIndex:t.currIndex]), unicode.IsSpace),
+		Start:      loc,
+		StartIndex: startIndex,
+		EndIndex:   t.currIndex,
+	})
+
 	return nil
 }
 
+// Comments returns every comment found by Tokenise, in the order they appear in
+func (t *Tokeniser) Comments() []Comment {
+	return t.comments
+}
+
+// span fills in where token came from, it has to be called right after the token was read
+func (t *Tokeniser) span(token Token, startIndex int) Token {
+	token.Raw = string(t.contents[startIndex:t.currIndex])
+	token.StartIndex = startIndex
+	token.EndIndex = t.currIndex
+
+	return token
+}
+
 func (t *Tokeniser) GetLineAndCol(index int) Location {
 	line := 1
 	col := 1
 
-	for i := 0; i < index; i++ {
+	for i := 0; i < index && i < len(t.contents); i++ {
 		if t.contents[i] == '\n' {
 			line++
 			col = 1
@@ -396,7 +461,7 @@ func (t *Tokeniser) GetCurrLineAndCol() Location {
 	return t.GetLineAndCol(t.currIndex)
 }
 
-func (t *Tokeniser) FormatErrorAt(message string, loc Location) error {
+func (t *Tokeniser) FormatErrorAt(code string, message string, loc Location) error {
 	var prettyFile string
 
 	if t.filePath == "" {
@@ -405,11 +470,18 @@ func (t *Tokeniser) FormatErrorAt(message string, loc Location) error {
 		prettyFile = path.Join(t.relativeDir, t.filePath)
 	}
 
-	return fmt.Errorf(fmt.Sprintf("%s:%d:%d - Tokeniser error: %s", prettyFile, loc.Line, loc.Col, message))
+	return &diagnostics.SyntaxError{
+		Diagnostic: diagnostics.Diagnostic{
+			Position: diagnostics.Position{File: prettyFile, Line: loc.Line, Col: loc.Col},
+			Stage:    diagnostics.STAGE_TOKENISER,
+			Code:     code,
+			Message:  message,
+		},
+	}
 }
 
-func (t *Tokeniser) FormatError(message string) error {
-	return t.FormatErrorAt(message, t.GetCurrLineAndCol())
+func (t *Tokeniser) FormatError(code string, message string) error {
+	return t.FormatErrorAt(code, message, t.GetCurrLineAndCol())
 }
 
 func IsAsciiDigit(c rune) bool {
@@ -438,6 +510,65 @@ func IsLegalWord(cs []rune) bool {
 	return true
 }
 
+// IsReservedWord reports whether a word is read as a literal rather than as a key
+func IsReservedWord(word string) bool {
+	switch word {
+	case "true", "yes", "on", "false", "no", "off", "null":
+		return true
+	default:
+		return false
+	}
+}
+
+// skipInvalid records err and steps over the rest of the offending word or number, so that one mistake is reported once
+func (t *Tokeniser) skipInvalid(err error, startIndex int, loc Location) Token {
+	t.errors.Add(err)
+
+	if t.currIndex <= startIndex {
+		t.currIndex = min(startIndex+1, len(t.contents))
+	}
+
+	for {
+		next := t.Peek()
+
+		if IsLegalWordStart(next) || IsAsciiDigit(next) || next == '.' {
+			t.Increment()
+		} else {
+			break
+		}
+	}
+
+	return t.span(InvalidToken(loc), startIndex)
+}
+
+// endsOperand reports whether the last token read can be the end of a value, a `-` after it is then a subtraction
+func endsOperand(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	switch tokens[len(tokens)-1].Type {
+	case TOKEN_TYPE_NUMBER_DECIMAL, TOKEN_TYPE_NUMBER_HEX, TOKEN_TYPE_NUMBER_BINARY, TOKEN_TYPE_STRING, TOKEN_TYPE_BOOL,
+		TOKEN_TYPE_NULL, TOKEN_TYPE_CONSTANT, TOKEN_TYPE_CLOSE_LIST, TOKEN_TYPE_CLOSE_OBJ, TOKEN_TYPE_CLOSE_PAREN:
+		return true
+	default:
+		return false
+	}
+}
+
+// readKeyDot adds a DOT token if a dot comes next, a dot right after a key separates it from the next segment of a dotted key
+func (t *Tokeniser) readKeyDot(tokens *[]Token) {
+	if t.Peek() != '.' {
+		return
+	}
+
+	dotIndex := t.currIndex
+	loc := t.GetCurrLineAndCol()
+	t.Increment()
+	*tokens = append(*tokens, t.span(DotToken(loc), dotIndex))
+}
+
+// Tokenise reads the whole input, when it runs into errors it keeps going and returns every error it found as a diagnostics.List
 func (t *Tokeniser) Tokenise() ([]Token, error) {
 	tokens := []Token{}
 
@@ -447,6 +578,7 @@ func (t *Tokeniser) Tokenise() ([]Token, error) {
 
 	for {
 		loc := t.GetCurrLineAndCol()
+		startIndex := t.currIndex
 		c := t.Peek()
 
 		if c == 0 {
@@ -457,17 +589,18 @@ func (t *Tokeniser) Tokenise() ([]Token, error) {
 			word, error := t.ReadWord()
 
 			if error != nil {
-				return nil, error
+				tokens = append(tokens, t.skipInvalid(error, startIndex, loc))
+				continue
 			}
 
 			if word == "true" || word == "yes" || word == "on" {
-				tokens = append(tokens, BoolToken("true", loc))
+				tokens = append(tokens, t.span(BoolToken("true", loc), startIndex))
 			} else if word == "false" || word == "no" || word == "off" {
-				tokens = append(tokens, BoolToken("false", loc))
+				tokens = append(tokens, t.span(BoolToken("false", loc), startIndex))
 			} else if word == "null" {
-				tokens = append(tokens, NullToken(loc))
+				tokens = append(tokens, t.span(NullToken(loc), startIndex))
 			} else {
-				tokens = append(tokens, KeyToken(word, loc))
+				tokens = append(tokens, t.span(KeyToken(word, loc), startIndex))
 
 				for {
 					next := t.Peek()
@@ -478,74 +611,127 @@ func (t *Tokeniser) Tokenise() ([]Token, error) {
 					}
 				}
 
-				if t.Peek() == '.' {
-					t.Increment()
-					tokens = append(tokens, DotToken(loc))
-				}
+				t.readKeyDot(&tokens)
 			}
+		} else if c == '-' && (endsOperand(tokens) || (!IsAsciiDigit(t.PeekAhead(1)) && t.PeekAhead(1) != '.')) {
+			// a minus right after a value is a subtraction, otherwise it's only an operator when it isn't the sign of a number
+			t.Increment()
+			tokens = append(tokens, t.span(MinusToken(loc), startIndex))
 		} else if IsAsciiDigit(c) || c == '-' || c == '.' {
 			number, mode, error := t.ReadNumber()
 
 			if error != nil {
-				return nil, error
+				tokens = append(tokens, t.skipInvalid(error, startIndex, loc))
+				continue
 			}
 
-			tokens = append(tokens, NumberToken(number, mode, loc))
+			tokens = append(tokens, t.span(NumberToken(number, mode, loc), startIndex))
 		} else if c == '"' {
 			parsed, constantSubs, error := t.ReadString()
 
 			if error != nil {
-				return nil, error
+				t.errors.Add(error)
+				tokens = append(tokens, t.span(InvalidToken(loc), startIndex))
+				continue
 			}
 
-			tokens = append(tokens, StringToken(parsed, constantSubs, loc))
+			tokens = append(tokens, t.span(StringToken(parsed, constantSubs, loc), startIndex))
+
+			// a quoted key can be followed by the rest of a dotted key too, `"a b".c`, but only right after the closing quote,
+			// otherwise `.5` would stop being a number
+			t.readKeyDot(&tokens)
 		} else if c == '#' {
-			t.IgnoreComment()
+			err := t.ReadComment()
+			if err != nil {
+				tokens = append(tokens, t.skipInvalid(err, startIndex, loc))
+				continue
+			}
 		} else {
 			t.Increment()
 
-			if c == '=' || c == ':' {
-				tokens = append(tokens, AssignToken(loc))
+			if c == '=' && t.Peek() == '=' {
+				t.Increment()
+				tokens = append(tokens, t.span(EqualToken(loc), startIndex))
+			} else if c == '=' || c == ':' {
+				tokens = append(tokens, t.span(AssignToken(loc), startIndex))
+			} else if c == '!' && t.Peek() == '=' {
+				t.Increment()
+				tokens = append(tokens, t.span(NotEqualToken(loc), startIndex))
+			} else if c == '!' {
+				tokens = append(tokens, t.span(NotToken(loc), startIndex))
+			} else if c == '<' && t.Peek() == '=' {
+				t.Increment()
+				tokens = append(tokens, t.span(LessEqualToken(loc), startIndex))
+			} else if c == '<' {
+				tokens = append(tokens, t.span(LessToken(loc), startIndex))
+			} else if c == '>' && t.Peek() == '=' {
+				t.Increment()
+				tokens = append(tokens, t.span(GreaterEqualToken(loc), startIndex))
+			} else if c == '>' {
+				tokens = append(tokens, t.span(GreaterToken(loc), startIndex))
+			} else if c == '&' && t.Peek() == '&' {
+				t.Increment()
+				tokens = append(tokens, t.span(AndToken(loc), startIndex))
+			} else if c == '|' && t.Peek() == '|' {
+				t.Increment()
+				tokens = append(tokens, t.span(OrToken(loc), startIndex))
+			} else if c == '+' && t.Peek() == '=' {
+				t.Increment()
+				tokens = append(tokens, t.span(MergeAssignToken(loc), startIndex))
+			} else if c == '+' {
+				tokens = append(tokens, t.span(PlusToken(loc), startIndex))
+			} else if c == '*' {
+				tokens = append(tokens, t.span(StarToken(loc), startIndex))
+			} else if c == '/' {
+				tokens = append(tokens, t.span(SlashToken(loc), startIndex))
+			} else if c == '%' {
+				tokens = append(tokens, t.span(PercentToken(loc), startIndex))
+			} else if c == '(' {
+				tokens = append(tokens, t.span(OpenParenToken(loc), startIndex))
+			} else if c == ')' {
+				tokens = append(tokens, t.span(CloseParenToken(loc), startIndex))
 			} else if c == '$' {
 				word, error := t.ReadWord()
 
 				if error != nil {
-					return nil, error
+					tokens = append(tokens, t.skipInvalid(error, startIndex, loc))
+					continue
 				}
 
-				tokens = append(tokens, ConstantToken(word, loc))
+				tokens = append(tokens, t.span(ConstantToken(word, loc), startIndex))
 			} else if c == '@' {
 				word, error := t.ReadWord()
 
 				if error != nil {
-					return nil, error
+					tokens = append(tokens, t.skipInvalid(error, startIndex, loc))
+					continue
 				}
 
-				tokens = append(tokens, DirectiveToken(word, loc))
+				tokens = append(tokens, t.span(DirectiveToken(word, loc), startIndex))
 			} else if c == '~' {
-				tokens = append(tokens, TildeToken(loc))
+				tokens = append(tokens, t.span(TildeToken(loc), startIndex))
 			} else if c == '|' {
-				tokens = append(tokens, PipeToken(loc))
+				tokens = append(tokens, t.span(PipeToken(loc), startIndex))
 			} else if c == '[' {
-				tokens = append(tokens, OpenListToken(loc))
+				tokens = append(tokens, t.span(OpenListToken(loc), startIndex))
 			} else if c == ']' {
-				tokens = append(tokens, CloseListToken(loc))
+				tokens = append(tokens, t.span(CloseListToken(loc), startIndex))
 			} else if c == '?' {
-				tokens = append(tokens, QuestionMarkToken(loc))
+				tokens = append(tokens, t.span(QuestionMarkToken(loc), startIndex))
 			} else if c == ',' {
-				tokens = append(tokens, CommaToken(loc))
+				tokens = append(tokens, t.span(CommaToken(loc), startIndex))
 			} else if c == '{' {
-				tokens = append(tokens, OpenObjToken(loc))
+				tokens = append(tokens, t.span(OpenObjToken(loc), startIndex))
 			} else if c == '}' {
-				tokens = append(tokens, CloseObjToken(loc))
+				tokens = append(tokens, t.span(CloseObjToken(loc), startIndex))
 			} else if unicode.IsSpace(c) {
 				continue
 			} else {
-				fmt.Println(t.FormatErrorAt(fmt.Sprintf("Unexpected character: `%c`", c), loc))
-				os.Exit(1)
+				t.errors.Add(t.FormatErrorAt(diagnostics.CODE_UNEXPECTED_CHARACTER, fmt.Sprintf("Unexpected character: `%c`", c), loc))
+				tokens = append(tokens, t.span(InvalidToken(loc), startIndex))
 			}
 		}
 	}
 
-	return tokens, nil
+	return tokens, t.errors.Err()
 }
@@ -0,0 +1,40 @@
+package tokeniser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marzeq/mconf/diagnostics"
+)
+
+func TestTokeniseTruncatedInput(t *testing.T) {
+	inputs := []string{
+		`h = "unterminated`,
+		`a = "${x`,
+		`a = "${`,
+		`a = "$`,
+		`a = $`,
+		`@`,
+		`a = "\`,
+		`a = "\x`,
+		`a = "\x1`,
+		`a = "\u12`,
+		`a = "\ud800\u`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			tok := NewTokeniser(input, "", "")
+
+			_, err := tok.Tokenise()
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+
+			var syntaxErr *diagnostics.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a syntax error, got %T: %v", err, err)
+			}
+		})
+	}
+}